@@ -7,6 +7,9 @@ const (
 	IdentifierExpressionKind
 	BinaryExpressionKind
 	FunctionCallExpressionKind
+	CastExpressionKind
+	ArrayExpressionKind
+	NullExpressionKind
 )
 
 type Expression struct {
@@ -14,13 +17,21 @@ type Expression struct {
 	Identifier   string
 	Binary       *BinaryExpression
 	FunctionCall FunctionCall
+	Cast         *CastExpression
+	Array        *[]Expression
 	Kind         ExpressionKind
 }
 
 type BinaryExpression struct {
-	A        Expression
-	B        Expression
-	Operator string
+	A          Expression
+	B          Expression
+	Operator   string
+	Quantifier string
+}
+
+type CastExpression struct {
+	Expression Expression
+	Type       string
 }
 
 type FunctionCall struct {
@@ -34,23 +45,60 @@ const (
 	SelectKind StatementKind = iota
 	InsertKind
 	CreateTableKind
+	CreateTypeKind
+	DropTableKind
+	TruncateTableKind
+	AlterTableKind
+	UpdateKind
+	DeleteKind
+	CreateSequenceKind
+	DropSequenceKind
+	CreateViewKind
+	DropViewKind
+	RefreshMaterializedViewKind
+	CreateSchemaKind
+	DropSchemaKind
+	SetKind
+	ShowKind
+	CreateTriggerKind
+	DropTriggerKind
 )
 
 type Statement struct {
-	Select      SelectStatement
-	Insert      InsertStatement
-	CreateTable CreateTableStatement
-	Kind        StatementKind
+	Select                  SelectStatement
+	Insert                  InsertStatement
+	CreateTable             CreateTableStatement
+	CreateType              CreateTypeStatement
+	DropTable               DropTableStatement
+	Truncate                TruncateTableStatement
+	AlterTable              AlterTableStatement
+	Update                  UpdateStatement
+	Delete                  DeleteStatement
+	CreateSequence          CreateSequenceStatement
+	DropSequence            DropSequenceStatement
+	CreateView              CreateViewStatement
+	DropView                DropViewStatement
+	RefreshMaterializedView RefreshMaterializedViewStatement
+	CreateSchema            CreateSchemaStatement
+	DropSchema              DropSchemaStatement
+	Set                     SetStatement
+	Show                    ShowStatement
+	CreateTrigger           CreateTriggerStatement
+	DropTrigger             DropTriggerStatement
+	Kind                    StatementKind
 }
 
 type SelectStatement struct {
-	Table   string
-	Items   *[]Expression
-	Where   Expression
-	GroupBy Expression
-	OrderBy OrderBy
-	Limit   int
-	Offset  int
+	Table string
+	// Function is the function returning the rows read, given in place of a
+	// table. Selects with neither a table nor a function read a single row
+	Function *FunctionCall
+	Items    *[]Expression
+	Where    Expression
+	GroupBy  Expression
+	OrderBy  OrderBy
+	Limit    int
+	Offset   int
 }
 
 type OrderBy struct {
@@ -61,15 +109,210 @@ type OrderBy struct {
 type InsertStatement struct {
 	Table   string
 	Columns *[]Expression
-	Values  *[]Expression
+	// Values holds the values of each inserted row
+	Values *[][]Expression
+	// Query is set instead of Values when rows come from a select statement
+	Query      *SelectStatement
+	OnConflict *OnConflict
+	// Returning holds the items returned for each inserted row
+	Returning *[]Expression
+}
+
+// OnConflict is the action taken for inserted rows conflicting with an
+// existing row in a unique index
+type OnConflict struct {
+	// Columns are the columns of the unique index checked for conflicts. Every
+	// unique index is checked when no columns are given
+	Columns *[]string
+	// Set is nil for 'do nothing', and holds the assignments applied to the
+	// existing row for 'do update'
+	Set   *[]UpdateAssignment
+	Where Expression
+	// Alias is the alias given to the table of the insert, which the existing
+	// row is then referenced by instead of the table name
+	Alias string
+}
+
+type UpdateStatement struct {
+	Table     string
+	Set       *[]UpdateAssignment
+	Where     Expression
+	Returning *[]Expression
+}
+
+type UpdateAssignment struct {
+	Column string
+	Value  Expression
+}
+
+type DeleteStatement struct {
+	Table     string
+	Where     Expression
+	Returning *[]Expression
 }
 
 type CreateTableStatement struct {
-	Name    string
-	Columns *[]ColumnDefinition
+	Name        string
+	Columns     *[]ColumnDefinition
+	Constraints *[]TableConstraint
+	Temporary   bool
 }
 
 type ColumnDefinition struct {
+	Name     string
+	Type     string
+	Enum     *EnumType
+	NotNull  bool
+	Default  Expression
+	Check    Expression
+	Identity IdentityKind
+	// Generated is the expression computing the value of a stored generated
+	// column from the other columns of its row
+	Generated Expression
+}
+
+// IdentityKind tells whether values of a column are generated by a sequence,
+// and whether they may be given explicitly
+type IdentityKind uint
+
+const (
+	NoIdentity IdentityKind = iota
+	IdentityByDefault
+	IdentityAlways
+)
+
+type ConstraintKind uint
+
+const (
+	PrimaryKeyConstraint ConstraintKind = iota
+	UniqueConstraint
+	ForeignKeyConstraint
+)
+
+type ForeignKeyAction uint
+
+const (
+	NoAction ForeignKeyAction = iota
+	RestrictAction
+	CascadeAction
+	SetNullAction
+)
+
+type TableConstraint struct {
+	Kind    ConstraintKind
+	Columns []string
+	// References, ReferencedColumns and OnDelete are only set for foreign keys.
+	// Referenced columns default to the primary key of the referenced table
+	References        string
+	ReferencedColumns []string
+	OnDelete          ForeignKeyAction
+}
+
+type CreateTypeStatement struct {
+	Name   string
+	Labels *[]string
+}
+
+type CreateSequenceStatement struct {
+	Name      string
+	Start     int
+	Increment int
+}
+
+type DropSequenceStatement struct {
+	Name     string
+	IfExists bool
+}
+
+type CreateViewStatement struct {
+	Name         string
+	Columns      *[]string
+	Query        string
+	Materialized bool
+}
+
+type DropViewStatement struct {
+	Name         string
+	IfExists     bool
+	Materialized bool
+}
+
+type RefreshMaterializedViewStatement struct {
+	Name string
+}
+
+type CreateSchemaStatement struct {
+	Name        string
+	IfNotExists bool
+}
+
+type DropSchemaStatement struct {
+	Name     string
+	IfExists bool
+}
+
+// SetStatement changes a setting of the session, such as search_path
+type SetStatement struct {
+	Name   string
+	Values *[]string
+}
+
+type ShowStatement struct {
+	Name string
+}
+
+type TriggerTiming uint
+
+const (
+	BeforeTrigger TriggerTiming = iota
+	AfterTrigger
+)
+
+type TriggerEvent uint
+
+const (
+	InsertTrigger TriggerEvent = iota
+	UpdateTrigger
+	DeleteTrigger
+)
+
+type CreateTriggerStatement struct {
+	Name   string
+	Table  string
+	Timing TriggerTiming
+	Event  TriggerEvent
+	// Body holds the text of each statement run by the trigger, which are
+	// parsed again whenever the trigger fires
+	Body *[]string
+}
+
+type DropTriggerStatement struct {
+	Name     string
+	Table    string
+	IfExists bool
+}
+
+type DropTableStatement struct {
+	Name     string
+	IfExists bool
+}
+
+type TruncateTableStatement struct {
 	Name string
-	Type string
+}
+
+type AlterTableAction uint
+
+const (
+	AddColumnAction AlterTableAction = iota
+	DropColumnAction
+	RenameColumnAction
+	RenameTableAction
+)
+
+type AlterTableStatement struct {
+	Table   string
+	Action  AlterTableAction
+	Column  ColumnDefinition
+	NewName string
 }
@@ -0,0 +1,218 @@
+package main
+
+type LiteralType uint
+
+const (
+	IntLiteral LiteralType = iota
+	StringLiteral
+	BytesLiteral
+)
+
+// writeExpression encodes an expression, so it can be stored in the catalog
+// and evaluated again later
+func writeExpression(buf *ByteStreamBuffer, expression Expression) {
+	buf.WriteInt(int(expression.Kind), SmallIntSize)
+	switch expression.Kind {
+	case LiteralExpressionKind:
+		switch literal := expression.Literal.(type) {
+		case int:
+			buf.WriteInt(int(IntLiteral), SmallIntSize)
+			buf.WriteInt(literal, IntSize)
+		case string:
+			buf.WriteInt(int(StringLiteral), SmallIntSize)
+			buf.WriteString(literal)
+		case []byte:
+			buf.WriteInt(int(BytesLiteral), SmallIntSize)
+			buf.WriteBytes(literal)
+		}
+	case IdentifierExpressionKind:
+		buf.WriteString(expression.Identifier)
+	case BinaryExpressionKind:
+		writeExpression(buf, expression.Binary.A)
+		writeExpression(buf, expression.Binary.B)
+		buf.WriteString(expression.Binary.Operator)
+		buf.WriteString(expression.Binary.Quantifier)
+	case FunctionCallExpressionKind:
+		buf.WriteString(expression.FunctionCall.Name)
+		writeExpressions(buf, *expression.FunctionCall.Params)
+	case CastExpressionKind:
+		writeExpression(buf, expression.Cast.Expression)
+		buf.WriteString(expression.Cast.Type)
+	case ArrayExpressionKind:
+		writeExpressions(buf, *expression.Array)
+	}
+}
+
+func writeExpressions(buf *ByteStreamBuffer, expressions []Expression) {
+	buf.WriteInt(len(expressions), SmallIntSize)
+	for _, expression := range expressions {
+		writeExpression(buf, expression)
+	}
+}
+
+func readExpression(buf *ByteStreamBuffer) Expression {
+	expression := Expression{Kind: ExpressionKind(buf.ReadInt(SmallIntSize))}
+	switch expression.Kind {
+	case LiteralExpressionKind:
+		switch LiteralType(buf.ReadInt(SmallIntSize)) {
+		case IntLiteral:
+			expression.Literal = int(int32(buf.ReadInt(IntSize)))
+		case StringLiteral:
+			expression.Literal = buf.ReadString()
+		case BytesLiteral:
+			expression.Literal = buf.ReadBytes()
+		}
+	case IdentifierExpressionKind:
+		expression.Identifier = buf.ReadString()
+	case BinaryExpressionKind:
+		expression.Binary = &BinaryExpression{A: readExpression(buf), B: readExpression(buf)}
+		expression.Binary.Operator = buf.ReadString()
+		expression.Binary.Quantifier = buf.ReadString()
+	case FunctionCallExpressionKind:
+		name := buf.ReadString()
+		params := readExpressions(buf)
+		expression.FunctionCall = FunctionCall{Name: name, Params: &params}
+	case CastExpressionKind:
+		expression.Cast = &CastExpression{Expression: readExpression(buf)}
+		expression.Cast.Type = buf.ReadString()
+	case ArrayExpressionKind:
+		elements := readExpressions(buf)
+		expression.Array = &elements
+	}
+	return expression
+}
+
+func readExpressions(buf *ByteStreamBuffer) []Expression {
+	expressions := make([]Expression, buf.ReadInt(SmallIntSize))
+	for i := range expressions {
+		expressions[i] = readExpression(buf)
+	}
+	return expressions
+}
+
+// expressionIdentifiers returns the names of the columns referenced by an
+// expression
+func expressionIdentifiers(expression Expression) []string {
+	var identifiers []string
+	walkExpression(&expression, func(expression *Expression) {
+		if expression.Kind == IdentifierExpressionKind {
+			identifiers = append(identifiers, expression.Identifier)
+		}
+	})
+	return identifiers
+}
+
+// findFunctionCall returns the name of the first function called by an
+// expression that matches
+func findFunctionCall(expression Expression, match func(string) bool) (string, bool) {
+	var name string
+	walkExpression(&expression, func(expression *Expression) {
+		if name == "" && expression.Kind == FunctionCallExpressionKind && match(expression.FunctionCall.Name) {
+			name = expression.FunctionCall.Name
+		}
+	})
+	return name, name != ""
+}
+
+// renameIdentifier returns a copy of an expression, with references to a
+// column replaced by its new name
+func renameIdentifier(expression Expression, name string, newName string) Expression {
+	expression = copyExpression(expression)
+	walkExpression(&expression, func(expression *Expression) {
+		if expression.Kind == IdentifierExpressionKind && expression.Identifier == name {
+			expression.Identifier = newName
+		}
+	})
+	return expression
+}
+
+// walkExpression calls visit for an expression and each of its children
+func walkExpression(expression *Expression, visit func(*Expression)) {
+	visit(expression)
+	switch expression.Kind {
+	case BinaryExpressionKind:
+		walkExpression(&expression.Binary.A, visit)
+		walkExpression(&expression.Binary.B, visit)
+	case FunctionCallExpressionKind:
+		for i := range *expression.FunctionCall.Params {
+			walkExpression(&(*expression.FunctionCall.Params)[i], visit)
+		}
+	case CastExpressionKind:
+		walkExpression(&expression.Cast.Expression, visit)
+	case ArrayExpressionKind:
+		for i := range *expression.Array {
+			walkExpression(&(*expression.Array)[i], visit)
+		}
+	}
+}
+
+// copyExpression returns a deep copy of an expression, so it can be changed
+// without changing the original one. Literal values are shared
+func copyExpression(expression Expression) Expression {
+	switch expression.Kind {
+	case BinaryExpressionKind:
+		binary := *expression.Binary
+		binary.A = copyExpression(binary.A)
+		binary.B = copyExpression(binary.B)
+		expression.Binary = &binary
+	case FunctionCallExpressionKind:
+		params := copyExpressions(*expression.FunctionCall.Params)
+		expression.FunctionCall.Params = &params
+	case CastExpressionKind:
+		cast := *expression.Cast
+		cast.Expression = copyExpression(cast.Expression)
+		expression.Cast = &cast
+	case ArrayExpressionKind:
+		elements := copyExpressions(*expression.Array)
+		expression.Array = &elements
+	}
+	return expression
+}
+
+func copyExpressions(expressions []Expression) []Expression {
+	copies := make([]Expression, len(expressions))
+	for i, expression := range expressions {
+		copies[i] = copyExpression(expression)
+	}
+	return copies
+}
+
+// statementExpressions returns the expressions of a statement changing data,
+// so they can be rewritten in place
+func statementExpressions(statement *Statement) []*Expression {
+	var expressions []*Expression
+	switch statement.Kind {
+	case InsertKind:
+		if query := statement.Insert.Query; query != nil {
+			for i := range *query.Items {
+				expressions = append(expressions, &(*query.Items)[i])
+			}
+			expressions = append(expressions, &query.Where)
+			if function := query.Function; function != nil {
+				for i := range *function.Params {
+					expressions = append(expressions, &(*function.Params)[i])
+				}
+			}
+		} else {
+			for _, values := range *statement.Insert.Values {
+				for i := range values {
+					expressions = append(expressions, &values[i])
+				}
+			}
+		}
+		if onConflict := statement.Insert.OnConflict; onConflict != nil && onConflict.Set != nil {
+			for i := range *onConflict.Set {
+				expressions = append(expressions, &(*onConflict.Set)[i].Value)
+			}
+			expressions = append(expressions, &onConflict.Where)
+		}
+	case UpdateKind:
+		for i := range *statement.Update.Set {
+			expressions = append(expressions, &(*statement.Update.Set)[i].Value)
+		}
+		expressions = append(expressions, &statement.Update.Where)
+	case DeleteKind:
+		expressions = append(expressions, &statement.Delete.Where)
+	}
+	return expressions
+}
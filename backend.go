@@ -1,17 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 )
 
 type Backend struct {
-	storage         Storage
-	currentRow      Row
-	tableDefinition TableDefinition
-	functionCalls   map[string]*FunctionCall
-	functionsData   map[string]map[string]*FunctionData
+	storage Storage
+	// temporaryStorage holds the temporary relations of this session
+	temporaryStorage Storage
+	currentRow       Row
+	tableDefinition  TableDefinition
+	functionCalls    map[string]*FunctionCall
+	functionsData    map[string]map[string]*FunctionData
+	// sequenceValues holds the latest value of each sequence in this session
+	sequenceValues map[string]int
+	// searchPath lists the schemas where unqualified names are looked up
+	searchPath []string
+	// triggerDepth counts the triggers firing the statement being run
+	triggerDepth int
 }
 
 type SelectRow struct {
@@ -24,51 +33,786 @@ type FunctionData struct {
 	Acc      interface{}
 }
 
-func NewBackend() *Backend {
-	return &Backend{storage: NewStorage()}
+func NewBackend() (*Backend, error) {
+	storage, err := NewStorage()
+	if err != nil {
+		return nil, err
+	}
+	temporaryStorage, err := NewTemporaryStorage(storage)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{
+		storage:          storage,
+		temporaryStorage: temporaryStorage,
+		sequenceValues:   make(map[string]int),
+		searchPath:       []string{PublicSchema},
+	}, nil
+}
+
+// Close drops the temporary relations of the session
+func (backend *Backend) Close() error {
+	return backend.temporaryStorage.Close()
+}
+
+// storageFor returns the storage holding a relation, which is the temporary
+// storage for relations created as temporary in this session
+func (backend Backend) storageFor(name string) Storage {
+	if backend.isTemporary(name) {
+		return backend.temporaryStorage
+	}
+	return backend.storage
+}
+
+func (backend Backend) isTemporary(name string) bool {
+	found, _ := backend.temporaryStorage.ownsRelation(name)
+	return found
+}
+
+// Run runs a statement and prints the rows it returns. Statements run from the
+// prompt either succeed as a whole or leave no changes behind, including those
+// made by the triggers they fire
+func (backend *Backend) Run(statement Statement) error {
+	if backend.triggerDepth > 0 {
+		_, err := backend.run(statement)
+		return err
+	}
+
+	storages := []Storage{backend.storage, backend.temporaryStorage}
+	for _, storage := range storages {
+		if err := storage.Begin(); err != nil {
+			return err
+		}
+	}
+	returnedData, err := backend.run(statement)
+	for _, storage := range storages {
+		if err != nil {
+			if rollbackErr := storage.Rollback(); rollbackErr != nil {
+				return rollbackErr
+			}
+		} else {
+			storage.Commit()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range returnedData {
+		fmt.Println(strings.Join(returnedData[i], ", "))
+	}
+	if returnedData != nil {
+		fmt.Println()
+	}
+	return nil
 }
 
-func (backend *Backend) Run(statement Statement) {
+func (backend *Backend) run(statement Statement) ([][]string, error) {
 	var returnedData [][]string
+
+	statement, err := backend.resolveNames(statement)
+	if err != nil {
+		return nil, err
+	}
 	switch statement.Kind {
 	case CreateTableKind:
-		backend.runCreateTable(statement.CreateTable)
+		err = backend.runCreateTable(statement.CreateTable)
+	case CreateTypeKind:
+		err = backend.runCreateType(statement.CreateType)
+	case DropTableKind:
+		err = backend.runDropTable(statement.DropTable)
+	case TruncateTableKind:
+		err = backend.runTruncateTable(statement.Truncate)
+	case AlterTableKind:
+		err = backend.runAlterTable(statement.AlterTable)
 	case InsertKind:
-		backend.runInsert(statement.Insert)
+		returnedData, err = backend.runInsert(statement.Insert)
+	case UpdateKind:
+		returnedData, err = backend.runUpdate(statement.Update)
+	case DeleteKind:
+		returnedData, err = backend.runDelete(statement.Delete)
+	case CreateSequenceKind:
+		err = backend.runCreateSequence(statement.CreateSequence)
+	case DropSequenceKind:
+		err = backend.runDropSequence(statement.DropSequence)
+	case CreateViewKind:
+		err = backend.runCreateView(statement.CreateView)
+	case DropViewKind:
+		err = backend.runDropView(statement.DropView)
+	case RefreshMaterializedViewKind:
+		err = backend.runRefreshMaterializedView(statement.RefreshMaterializedView)
+	case CreateSchemaKind:
+		err = backend.runCreateSchema(statement.CreateSchema)
+	case DropSchemaKind:
+		err = backend.runDropSchema(statement.DropSchema)
+	case SetKind:
+		err = backend.runSet(statement.Set)
+	case ShowKind:
+		returnedData, err = backend.runShow(statement.Show)
+	case CreateTriggerKind:
+		err = backend.runCreateTrigger(statement.CreateTrigger)
+	case DropTriggerKind:
+		err = backend.runDropTrigger(statement.DropTrigger)
 	case SelectKind:
-		returnedData = backend.runSelect(statement.Select)
+		returnedData, err = backend.runSelect(statement.Select)
 	}
+	return returnedData, err
+}
 
-	for i := range returnedData {
-		fmt.Println(strings.Join(returnedData[i], ", "))
+func (backend Backend) runCreateTable(statement CreateTableStatement) error {
+	for _, column := range *statement.Columns {
+		if err := validateColumnExpressions(column, *statement.Columns); err != nil {
+			return err
+		}
 	}
-	if returnedData != nil {
-		fmt.Println()
+	storage := backend.storageFor(statement.Name)
+	if statement.Temporary {
+		storage = backend.temporaryStorage
+	}
+	// Temporary and permanent tables cannot reference each other, as their
+	// rows live in different storages
+	for _, constraint := range *statement.Constraints {
+		if constraint.Kind != ForeignKeyConstraint || constraint.References == statement.Name {
+			continue
+		}
+		if backend.isTemporary(constraint.References) != statement.Temporary {
+			if statement.Temporary {
+				return errors.New("constraints on temporary tables may reference only temporary tables")
+			}
+			return errors.New("constraints on permanent tables may reference only permanent tables")
+		}
+	}
+	return storage.CreateTable(statement.Name, *statement.Columns, *statement.Constraints)
+}
+
+func (backend Backend) runCreateType(statement CreateTypeStatement) error {
+	return backend.storage.CreateType(statement.Name, *statement.Labels)
+}
+
+func (backend Backend) runCreateSequence(statement CreateSequenceStatement) error {
+	return backend.storageFor(statement.Name).CreateSequence(Sequence{
+		Name:      statement.Name,
+		Start:     statement.Start,
+		Increment: statement.Increment,
+	})
+}
+
+func (backend Backend) runDropSequence(statement DropSequenceStatement) error {
+	storage := backend.storageFor(statement.Name)
+	if _, err := storage.GetSequence(statement.Name); err != nil && statement.IfExists {
+		return nil
+	}
+	return storage.DropSequence(statement.Name)
+}
+
+func (backend Backend) runCreateView(statement CreateViewStatement) error {
+	view := View{Name: statement.Name, Query: statement.Query}
+	query, err := parseViewQuery(view)
+	if err != nil {
+		return err
+	}
+	query.Table = backend.resolveName(query.Table)
+	if view.Columns, err = backend.viewColumns(statement, query); err != nil {
+		return err
+	}
+	// Views selecting from temporary relations are temporary as well
+	storage := backend.storageFor(view.Name)
+	if backend.isTemporary(query.Table) {
+		if statement.Materialized {
+			return errors.New("materialized views must not use temporary tables or views")
+		}
+		storage = backend.temporaryStorage
+	}
+	if !statement.Materialized {
+		return storage.CreateView(view)
+	}
+
+	rows, err := backend.viewRows(view)
+	if err != nil {
+		return err
+	}
+	columns, err := backend.materializedColumns(view, rows)
+	if err != nil {
+		return err
+	}
+	return storage.CreateMaterializedView(view, columns, rows)
+}
+
+func (backend Backend) runDropView(statement DropViewStatement) error {
+	view, found, err := backend.storageFor(statement.Name).GetView(statement.Name)
+	if err != nil {
+		return err
+	}
+	if !found && statement.IfExists {
+		return nil
+	}
+	if found && view.Materialized != statement.Materialized {
+		return fmt.Errorf("%s is not a %s", statement.Name, View{Materialized: statement.Materialized}.kind())
+	}
+	if err := backend.dependentViewsError(view.kind(), statement.Name, "drop"); err != nil {
+		return err
+	}
+	return backend.storageFor(statement.Name).DropView(statement.Name)
+}
+
+// runRefreshMaterializedView runs the query of a materialized view again,
+// replacing its rows with the new result
+func (backend Backend) runRefreshMaterializedView(statement RefreshMaterializedViewStatement) error {
+	view, found, err := backend.storageFor(statement.Name).GetView(statement.Name)
+	if err != nil {
+		return err
+	}
+	if !found || !view.Materialized {
+		return fmt.Errorf("materialized view %s does not exist", statement.Name)
+	}
+	rows, err := backend.viewRows(view)
+	if err != nil {
+		return err
+	}
+	return backend.storageFor(statement.Name).RefreshMaterializedView(statement.Name, rows)
+}
+
+func (backend Backend) runDropTable(statement DropTableStatement) error {
+	if err := backend.tableOnlyError(statement.Name); err != nil {
+		return err
+	}
+	storage := backend.storageFor(statement.Name)
+	if _, err := storage.GetTableDefinition(statement.Name); err != nil && statement.IfExists {
+		return nil
+	}
+	if err := backend.dependentViewsError("table", statement.Name, "drop"); err != nil {
+		return err
+	}
+	return storage.DropTable(statement.Name)
+}
+
+func (backend Backend) runTruncateTable(statement TruncateTableStatement) error {
+	if err := backend.tableOnlyError(statement.Name); err != nil {
+		return err
+	}
+	return backend.storageFor(statement.Name).TruncateTable(statement.Name)
+}
+
+func (backend Backend) runAlterTable(statement AlterTableStatement) error {
+	if err := backend.tableOnlyError(statement.Table); err != nil {
+		return err
+	}
+	switch statement.Action {
+	case AddColumnAction:
+		return backend.runAddColumn(statement.Table, statement.Column)
+	case DropColumnAction:
+		if err := backend.dependentColumnViewsError(statement.Table, statement.Column.Name, "drop"); err != nil {
+			return err
+		}
+		return backend.storageFor(statement.Table).DropColumn(statement.Table, statement.Column.Name)
+	case RenameColumnAction:
+		if err := backend.dependentColumnViewsError(statement.Table, statement.Column.Name, "rename"); err != nil {
+			return err
+		}
+		return backend.storageFor(statement.Table).RenameColumn(statement.Table, statement.Column.Name, statement.NewName)
+	case RenameTableAction:
+		if err := backend.dependentViewsError("table", statement.Table, "rename"); err != nil {
+			return err
+		}
+		return backend.storageFor(statement.Table).RenameTable(statement.Table, statement.NewName)
+	}
+	return nil
+}
+
+// runAddColumn adds a column to a table. Existing rows take the default value
+// of the column, which must satisfy its constraints
+func (backend Backend) runAddColumn(tableName string, column ColumnDefinition) error {
+	var err error
+
+	storage := backend.storageFor(tableName)
+	backend.tableDefinition, err = storage.GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+	if column.Identity != NoIdentity {
+		return fmt.Errorf("identity column %s cannot be added to an existing table", column.Name)
+	}
+	columns := append(backend.tableDefinition.Columns, column)
+	if err := validateColumnExpressions(column, columns); err != nil {
+		return err
+	}
+
+	var missing interface{}
+	if column.Default != (Expression{}) {
+		if missing, err = backend.evaluateExpression(column.Default, ""); err != nil {
+			return err
+		}
+	}
+
+	// Validate existing rows against the new column
+	generated := column.Generated != (Expression{})
+	if column.NotNull || column.Check != (Expression{}) || generated {
+		backend.tableDefinition.Columns = columns
+		backend.tableDefinition.ColumnIndexes[column.Name] = len(columns) - 1
+		for _, row := range storage.TableRows(tableName) {
+			row.Values = append(row.Values, RowValue{Column: column.Name, Value: missing})
+			if row, err = backend.generateColumns(row); err != nil {
+				return err
+			}
+			if err := backend.checkRow(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := storage.AddColumn(tableName, column, missing); err != nil || !generated {
+		return err
+	}
+	// Existing rows are rewritten with the values of the generated column
+	return storage.RewriteRows(tableName, func(row Row) (Row, bool, error) {
+		row, err := backend.generateColumns(row)
+		return row, true, err
+	})
+}
+
+func (backend Backend) runInsert(statement InsertStatement) ([][]string, error) {
+	var err error
+
+	if err := backend.tableOnlyError(statement.Table); err != nil {
+		return nil, err
+	}
+	backend.tableDefinition, err = backend.storageFor(statement.Table).GetTableDefinition(statement.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement.OnConflict != nil && statement.OnConflict.Set != nil {
+		if err := backend.checkAssignments(*statement.OnConflict.Set); err != nil {
+			return nil, err
+		}
+	}
+	if err := backend.checkReturning(statement.Returning); err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	if statement.Query != nil {
+		rows, err = backend.insertSelect(statement)
+	} else {
+		rows, err = backend.insertValues(statement)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return backend.returningValues(statement.Returning, rows)
+}
+
+// insertValues inserts the rows of the values lists of an insert statement
+func (backend Backend) insertValues(statement InsertStatement) ([]Row, error) {
+	columns, err := backend.insertColumns(statement, len((*statement.Values)[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	// Values cannot reference columns, as there is no row to read them from,
+	// and are evaluated once for their row
+	for _, row := range *statement.Values {
+		if len(row) != len((*statement.Values)[0]) {
+			return nil, errors.New("values lists must all be the same length")
+		}
+		for _, value := range row {
+			if identifiers := expressionIdentifiers(value); len(identifiers) > 0 {
+				return nil, fmt.Errorf("column %s does not exist", identifiers[0])
+			}
+			if err := checkRowExpression(value, "values"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var rows []map[string]interface{}
+	for _, row := range *statement.Values {
+		values := make(map[string]interface{})
+		for i := range row {
+			value, err := backend.evaluateExpression(row[i], "")
+			if err != nil {
+				return nil, err
+			}
+			values[columns[i].Identifier] = value
+		}
+		rows = append(rows, values)
+	}
+	return backend.insertRows(rows, statement.OnConflict)
+}
+
+// insertColumns returns the columns receiving the given number of values in
+// an insert. Without a columns list, values fill the columns of the table in
+// order
+func (backend Backend) insertColumns(statement InsertStatement, count int) ([]Expression, error) {
+	var columns []Expression
+	if statement.Columns != nil {
+		columns = *statement.Columns
+	} else {
+		for _, column := range backend.tableDefinition.Columns {
+			columns = append(columns, Expression{Kind: IdentifierExpressionKind, Identifier: column.Name})
+		}
+		if count < len(columns) {
+			columns = columns[:count]
+		}
+	}
+	if count > len(columns) {
+		return nil, errors.New("insert has more expressions than target columns")
+	}
+	if count < len(columns) {
+		return nil, errors.New("insert has more target columns than expressions")
+	}
+	return columns, nil
+}
+
+// insertSelect inserts the rows of a select statement as it produces them,
+// so its whole result is never held in memory. When the select reads from the
+// table being inserted into, its whole result is read first, so inserted rows
+// are not read again
+func (backend Backend) insertSelect(statement InsertStatement) ([]Row, error) {
+	query := *statement.Query
+	query.Table = backend.resolveName(query.Table)
+	source, err := backend.selectDefinition(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Items are checked against the columns before any row is read
+	items := expandSelectItems(*query.Items, source)
+	columns, err := backend.insertColumns(statement, len(items))
+	if err != nil {
+		return nil, err
+	}
+	for i, itemType := range selectItemTypes(items, source) {
+		index, ok := backend.tableDefinition.ColumnIndexes[columns[i].Identifier]
+		if !ok {
+			return nil, fmt.Errorf("column %s of relation %s does not exist", columns[i].Identifier, statement.Table)
+		}
+		column := backend.tableDefinition.Columns[index]
+		// Text is only converted into other types when written as a literal,
+		// as other text expressions may hold any string
+		compatible := canCoerceColumn(column, itemType)
+		if itemType == "text" && items[i].Kind != LiteralExpressionKind {
+			compatible = column.Type == "text"
+		}
+		if itemType != "" && !compatible {
+			return nil, fmt.Errorf("column %s is of type %s but expression is of type %s", column.Name, column.Type, itemType)
+		}
+	}
+
+	var inserted []Row
+	insert := func(items []interface{}) error {
+		values := make(map[string]interface{})
+		for i, column := range columns {
+			values[column.Identifier] = items[i]
+		}
+		rows, err := backend.insertRows([]map[string]interface{}{values}, statement.OnConflict)
+		inserted = append(inserted, rows...)
+		return err
+	}
+	if query.Table != statement.Table {
+		_, err := backend.streamSelect(query, insert)
+		return inserted, err
+	}
+	_, rows, err := backend.querySelect(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := insert(row); err != nil {
+			return nil, err
+		}
+	}
+	return inserted, nil
+}
+
+// insertRows inserts rows with the given column values into the table of the
+// backend as a single batch, firing its insert triggers for each of them.
+// Rows conflicting with existing rows are skipped or update them instead when
+// an on conflict clause is given. The inserted and updated rows are returned
+func (backend Backend) insertRows(rows []map[string]interface{}, onConflict *OnConflict) ([]Row, error) {
+	tableName := backend.tableDefinition.Name
+	var newRows []Row
+	for _, columnValues := range rows {
+		row, err := backend.newRow(columnValues)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.checkRow(row); err != nil {
+			return nil, err
+		}
+		newRows = append(newRows, row)
+	}
+
+	var conflicts map[string]Row
+	if onConflict != nil {
+		var err error
+		if newRows, conflicts, err = backend.resolveConflicts(newRows, *onConflict); err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []RowChange
+	var values [][]RowValue
+	for i := range newRows {
+		changes = append(changes, RowChange{New: &newRows[i]})
+		values = append(values, newRows[i].Values)
+	}
+	if err := backend.fireTriggers(tableName, BeforeTrigger, InsertTrigger, changes); err != nil {
+		return nil, err
+	}
+	if err := backend.storageFor(tableName).InsertRows(tableName, values); err != nil {
+		return nil, err
+	}
+	if err := backend.fireTriggers(tableName, AfterTrigger, InsertTrigger, changes); err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		return newRows, nil
+	}
+	updated, err := backend.updateConflicts(conflicts, *onConflict)
+	if err != nil {
+		return nil, err
+	}
+	return append(newRows, updated...), nil
+}
+
+func (backend Backend) runUpdate(statement UpdateStatement) ([][]string, error) {
+	var err error
+
+	if err := backend.tableOnlyError(statement.Table); err != nil {
+		return nil, err
+	}
+	backend.tableDefinition, err = backend.storageFor(statement.Table).GetTableDefinition(statement.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.checkAssignments(*statement.Set); err != nil {
+		return nil, err
+	}
+	if err := backend.checkReturning(statement.Returning); err != nil {
+		return nil, err
+	}
+
+	rows, err := backend.changeRows(UpdateTrigger, func(row Row) (Row, bool, error) {
+		backend.currentRow = row
+		if statement.Where != (Expression{}) {
+			matches, err := backend.evaluateExpression(statement.Where, "")
+			if err != nil || matches != true {
+				return row, false, err
+			}
+		}
+		updated, err := backend.assignValues(row, *statement.Set)
+		return updated, err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return backend.returningValues(statement.Returning, rows)
+}
+
+// checkAssignments checks the columns of update assignments can be set
+func (backend Backend) checkAssignments(assignments []UpdateAssignment) error {
+	for _, assignment := range assignments {
+		index, ok := backend.tableDefinition.ColumnIndexes[assignment.Column]
+		if !ok {
+			return fmt.Errorf("column %s does not exist", assignment.Column)
+		}
+		if backend.tableDefinition.Columns[index].Identity == IdentityAlways {
+			return fmt.Errorf("column %s can only be generated by its sequence", assignment.Column)
+		}
+		if backend.tableDefinition.Columns[index].Generated != (Expression{}) {
+			return fmt.Errorf("column %s can only be generated by its expression", assignment.Column)
+		}
+	}
+	return nil
+}
+
+// assignValues returns a row with update assignments applied to it, once its
+// generated columns and constraints are checked again
+func (backend Backend) assignValues(row Row, assignments []UpdateAssignment) (Row, error) {
+	backend.currentRow = row
+	// Values are evaluated against the row before any assignment
+	values := make([]RowValue, len(row.Values))
+	copy(values, row.Values)
+	for _, assignment := range assignments {
+		value, err := backend.evaluateExpression(assignment.Value, "")
+		if err != nil {
+			return row, err
+		}
+		index := backend.tableDefinition.ColumnIndexes[assignment.Column]
+		if values[index].Value, err = coerceColumnValue(backend.tableDefinition.Columns[index], value); err != nil {
+			return row, err
+		}
+	}
+	updated, err := backend.generateColumns(Row{Values: values})
+	if err != nil {
+		return row, err
+	}
+	if err := backend.checkRow(updated); err != nil {
+		return row, err
+	}
+	return updated, nil
+}
+
+func (backend Backend) runDelete(statement DeleteStatement) ([][]string, error) {
+	var err error
+
+	if err := backend.tableOnlyError(statement.Table); err != nil {
+		return nil, err
+	}
+	backend.tableDefinition, err = backend.storageFor(statement.Table).GetTableDefinition(statement.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.checkReturning(statement.Returning); err != nil {
+		return nil, err
+	}
+
+	rows, err := backend.changeRows(DeleteTrigger, func(row Row) (Row, bool, error) {
+		if statement.Where == (Expression{}) {
+			return row, true, nil
+		}
+		backend.currentRow = row
+		matches, err := backend.evaluateExpression(statement.Where, "")
+		if err != nil {
+			return row, false, err
+		}
+		return row, matches == true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return backend.returningValues(statement.Returning, rows)
+}
+
+// changeRows updates or deletes the rows of the table of the backend, firing
+// its triggers for the event. change returns the new values of a row, and
+// false for rows that are left as they are. The updated rows, or the deleted
+// ones, are returned
+func (backend Backend) changeRows(event TriggerEvent, change func(Row) (Row, bool, error)) ([]Row, error) {
+	tableName := backend.tableDefinition.Name
+
+	// Before triggers fire for the rows that will be changed, before any of
+	// them is written
+	if len(backend.triggers(tableName, BeforeTrigger, event)) > 0 {
+		changes, err := backend.rowChanges(tableName, event, change)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.fireTriggers(tableName, BeforeTrigger, event, changes); err != nil {
+			return nil, err
+		}
 	}
+
+	var changes []RowChange
+	var rows []Row
+	err := backend.storageFor(tableName).RewriteRows(tableName, func(row Row) (Row, bool, error) {
+		newRow, matches, err := change(row)
+		if err != nil || !matches {
+			return row, err == nil, err
+		}
+		if event == DeleteTrigger {
+			changes = append(changes, RowChange{Old: &row})
+			rows = append(rows, row)
+			return row, false, nil
+		}
+		changes = append(changes, RowChange{New: &newRow, Old: &row})
+		rows = append(rows, newRow)
+		return newRow, true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, backend.fireTriggers(tableName, AfterTrigger, event, changes)
 }
 
-func (backend Backend) runCreateTable(statement CreateTableStatement) {
-	backend.storage.CreateTable(statement.Name, *statement.Columns)
+// checkReturning checks the items of a returning clause can be evaluated for
+// each row
+func (backend Backend) checkReturning(returning *[]Expression) error {
+	if returning == nil {
+		return nil
+	}
+	for _, item := range *returning {
+		if err := checkRowExpression(item, "returning"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returningValues evaluates the items of a returning clause against each row
+// changed by a statement, returning them like the result of a select
+func (backend Backend) returningValues(returning *[]Expression, rows []Row) ([][]string, error) {
+	if returning == nil {
+		return nil, nil
+	}
+	var response [][]string
+	items := expandSelectItems(*returning, backend.tableDefinition)
+	for _, row := range rows {
+		backend.currentRow = row
+		values := make([]string, len(items))
+		for i, item := range items {
+			value, err := backend.evaluateExpression(item, "")
+			if err != nil {
+				return nil, err
+			}
+			values[i] = interfaceToString(value)
+		}
+		response = append(response, values)
+	}
+	return response, nil
 }
 
-func (backend Backend) runInsert(statement InsertStatement) {
-	var rows []RowValue
-	for i := range *statement.Values {
-		row := RowValue{
-			Column: (*statement.Columns)[i].Identifier,
-			Value:  (*statement.Values)[i].Literal,
+func (backend Backend) runSelect(statement SelectStatement) ([][]string, error) {
+	var response [][]string
+
+	_, rows, err := backend.querySelect(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	// Turn rows into [][]string response
+	for _, row := range rows {
+		rowItems := make([]string, len(row))
+		for i, item := range row {
+			rowItems[i] = interfaceToString(item)
 		}
-		rows = append(rows, row)
+		response = append(response, rowItems)
 	}
-	backend.storage.InsertInto(statement.Table, rows)
+	return response, nil
 }
 
-func (backend Backend) runSelect(statement SelectStatement) [][]string {
+// querySelect runs a select statement, returning the names of the selected
+// items along with the values of each row
+func (backend Backend) querySelect(statement SelectStatement) ([]string, [][]interface{}, error) {
+	var result [][]interface{}
+	names, err := backend.streamSelect(statement, func(values []interface{}) error {
+		result = append(result, values)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return names, result, nil
+}
+
+// streamSelect runs a select statement, passing the values of each row to
+// emit as soon as they are known. Rows of grouped or sorted queries are only
+// known once every row has been read
+func (backend Backend) streamSelect(statement SelectStatement, emit func([]interface{}) error) ([]string, error) {
 	var resultSet []*SelectRow
 	var groupedData map[string]*SelectRow
-	var response [][]string
+	var err error
 
-	backend.tableDefinition, _ = backend.storage.GetTableDefinition(statement.Table)
+	statement.Table = backend.resolveName(statement.Table)
+	backend.tableDefinition, err = backend.selectDefinition(statement)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := backend.selectRows(statement)
+	if err != nil {
+		return nil, err
+	}
 
 	items := expandSelectItems(*statement.Items, backend.tableDefinition)
 
@@ -76,31 +820,48 @@ func (backend Backend) runSelect(statement SelectStatement) [][]string {
 	backend.functionCalls = make(map[string]*FunctionCall)
 	backend.functionsData = make(map[string]map[string]*FunctionData)
 	for _, item := range items {
-		if item.Kind == FunctionCallExpressionKind {
+		if item.Kind == FunctionCallExpressionKind && isAggregateFunction(item.FunctionCall.Name) {
 			backend.functionCalls[item.FunctionCall.Name] = &item.FunctionCall
 		}
 	}
 
 	// Should group data if group by is specified or if statement contains
-	// aggregate functions (currently, all functions are aggregate functions)
+	// aggregate functions
 	grouping := statement.GroupBy != (Expression{}) || len(backend.functionCalls) > 0
 	if grouping {
 		groupedData = make(map[string]*SelectRow)
+		for _, item := range items {
+			if item.Kind == FunctionCallExpressionKind && isSetReturningFunction(item.FunctionCall.Name) {
+				return nil, fmt.Errorf("function %s is not supported in grouped queries", item.FunctionCall.Name)
+			}
+		}
 	}
 
+	// Rows of the result are emitted from the offset up to the limit
+	var emitted int
+	window := func(selectRow *SelectRow) error {
+		emitted++
+		if emitted <= statement.Offset || (statement.Limit != -1 && emitted > statement.Limit) {
+			return nil
+		}
+		return emit(selectRow.Items)
+	}
+	streaming := !grouping && statement.OrderBy == (OrderBy{})
+
 	// Sequential scan through table rows
-	for index, row := range backend.storage.TableRows(statement.Table) {
+	for _, row := range rows {
 		backend.currentRow = row
 		// Break loop after reaching limit
-		if statement.Limit != -1 &&
-			index >= statement.Limit &&
-			!grouping &&
-			statement.OrderBy == (OrderBy{}) {
+		if statement.Limit != -1 && emitted >= statement.Limit && streaming {
 			break
 		}
 		// Apply where condition
 		if statement.Where != (Expression{}) {
-			if backend.evaluateExpression(statement.Where, "") != true {
+			matches, err := backend.evaluateExpression(statement.Where, "")
+			if err != nil {
+				return nil, err
+			}
+			if matches != true {
 				continue
 			}
 		}
@@ -109,7 +870,11 @@ func (backend Backend) runSelect(statement SelectStatement) [][]string {
 		selectRow := new(SelectRow)
 		if grouping {
 			if statement.GroupBy != (Expression{}) {
-				groupKey = interfaceToString(backend.evaluateExpression(statement.GroupBy, groupKey))
+				value, err := backend.evaluateExpression(statement.GroupBy, groupKey)
+				if err != nil {
+					return nil, err
+				}
+				groupKey = interfaceToString(value)
 			}
 			groupedData[groupKey] = selectRow
 			// Process aggregate functions
@@ -127,16 +892,31 @@ func (backend Backend) runSelect(statement SelectStatement) [][]string {
 					fdata.Acc = fdata.Acc.(int) + 1
 				}
 			}
-		} else {
-			resultSet = append(resultSet, selectRow)
 		}
 		// Select items from row
 		for _, item := range items {
-			selectRow.Items = append(selectRow.Items, backend.evaluateExpression(item, groupKey))
+			value, err := backend.evaluateExpression(item, groupKey)
+			if err != nil {
+				return nil, err
+			}
+			selectRow.Items = append(selectRow.Items, value)
 		}
 		// Evaluate and store value for order by
 		if statement.OrderBy != (OrderBy{}) {
-			selectRow.OrderBy = backend.evaluateExpression(statement.OrderBy.By, groupKey)
+			selectRow.OrderBy, err = backend.evaluateExpression(statement.OrderBy.By, groupKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if grouping {
+			continue
+		}
+		for _, selectRow := range expandSetReturningItems(selectRow, items) {
+			if !streaming {
+				resultSet = append(resultSet, selectRow)
+			} else if err := window(selectRow); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -148,60 +928,113 @@ func (backend Backend) runSelect(statement SelectStatement) [][]string {
 	}
 	// Sort results
 	if statement.OrderBy != (OrderBy{}) {
+		var sortErr error
 		sort.Slice(resultSet, func(i, j int) bool {
+			var less bool
+			var err error
 			if statement.OrderBy.Direction == "asc" {
-				return evaluateALtB(resultSet[i].OrderBy, resultSet[j].OrderBy)
+				less, err = evaluateALtB(resultSet[i].OrderBy, resultSet[j].OrderBy)
 			} else {
-				return evaluateAGtB(resultSet[i].OrderBy, resultSet[j].OrderBy)
+				less, err = evaluateAGtB(resultSet[i].OrderBy, resultSet[j].OrderBy)
 			}
+			if err != nil && sortErr == nil {
+				sortErr = err
+			}
+			return less
 		})
-	}
-	// Turn result set into [][]string response
-	for _, row := range resultSet {
-		rowItems := make([]string, len(row.Items))
-		for i, item := range row.Items {
-			rowItems[i] = interfaceToString(item)
+		if sortErr != nil {
+			return nil, sortErr
 		}
-		response = append(response, rowItems)
 	}
-	// Apply limit
-	limit := statement.Limit
-	if limit == -1 || limit > len(response) {
-		limit = len(response)
-	}
-	// Apply offset
-	offset := statement.Offset
-	if statement.Offset == -1 {
-		offset = 0
+	for _, selectRow := range resultSet {
+		if err := window(selectRow); err != nil {
+			return nil, err
+		}
 	}
-	return response[offset:limit]
+	return selectItemNames(items), nil
 }
 
-func (backend Backend) evaluateExpression(expression Expression, groupKey string) interface{} {
+func (backend Backend) evaluateExpression(expression Expression, groupKey string) (interface{}, error) {
 	switch expression.Kind {
 	case IdentifierExpressionKind:
-		return backend.currentRow.Values[backend.tableDefinition.ColumnIndexes[expression.Identifier]].Value
+		index, ok := backend.tableDefinition.ColumnIndexes[expression.Identifier]
+		if !ok {
+			return nil, fmt.Errorf("column %s does not exist", expression.Identifier)
+		}
+		return backend.currentRow.Values[index].Value, nil
 	case FunctionCallExpressionKind:
-		return backend.functionsData[groupKey][expression.FunctionCall.Name].Acc
+		if isAggregateFunction(expression.FunctionCall.Name) {
+			return backend.functionsData[groupKey][expression.FunctionCall.Name].Acc, nil
+		}
+		var args []interface{}
+		for _, param := range *expression.FunctionCall.Params {
+			arg, err := backend.evaluateExpression(param, groupKey)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if isSequenceFunction(expression.FunctionCall.Name) {
+			return backend.callSequenceFunction(expression.FunctionCall.Name, args)
+		}
+		return callScalarFunction(expression.FunctionCall.Name, args)
 	case BinaryExpressionKind:
-		a := backend.evaluateExpression(expression.Binary.A, groupKey)
-		b := backend.evaluateExpression(expression.Binary.B, groupKey)
+		a, err := backend.evaluateExpression(expression.Binary.A, groupKey)
+		if err != nil {
+			return nil, err
+		}
+		b, err := backend.evaluateExpression(expression.Binary.B, groupKey)
+		if err != nil {
+			return nil, err
+		}
 		switch expression.Binary.Operator {
-		case "=":
-			return a == b
-		case "<>":
-			return a != b
-		case ">":
-			return evaluateAGtB(a, b)
-		case ">=":
-			return evaluateAGteB(a, b)
-		case "<":
-			return evaluateALtB(a, b)
-		case "<=":
-			return evaluateALteB(a, b)
+		case "->", "->>":
+			document, found, err := extractJsonPath(a, []interface{}{b})
+			if err != nil || !found {
+				return nil, err
+			}
+			if expression.Binary.Operator == "->>" {
+				return jsonToText(document), nil
+			}
+			return document, nil
+		case "+", "-", "*", "/", "%":
+			return evaluateArithmetic(expression.Binary.Operator, a, b)
+		}
+		if expression.Binary.Operator == "[]" {
+			return arrayElement(a, b)
+		}
+		if expression.Binary.Quantifier != "" {
+			return evaluateQuantifiedComparison(expression.Binary.Operator, expression.Binary.Quantifier, a, b)
+		}
+		return evaluateComparison(expression.Binary.Operator, a, b)
+	case CastExpressionKind:
+		value, err := backend.evaluateExpression(expression.Cast.Expression, groupKey)
+		if err != nil {
+			return nil, err
+		}
+		// Types other than the built-in ones are user-defined enums
+		if columnTypeFromString(expression.Cast.Type) == UnknownColumnType {
+			enum, err := backend.storage.GetEnumType(expression.Cast.Type)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			return enum.Value(value)
+		}
+		return castValue(value, expression.Cast.Type)
+	case ArrayExpressionKind:
+		elements := make([]interface{}, len(*expression.Array))
+		for i := range *expression.Array {
+			element, err := backend.evaluateExpression((*expression.Array)[i], groupKey)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
 		}
+		return elements, nil
 	case LiteralExpressionKind:
-		return expression.Literal
+		return expression.Literal, nil
+	case NullExpressionKind:
+		return nil, nil
 	}
-	return "?"
+	return "?", nil
 }
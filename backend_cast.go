@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// implicitCoercions lists, for each value type, the column types it can be
+// converted into without an explicit cast. Values are coerced when inserted
+// into a column of a different type, and when compared against a text value
+var implicitCoercions = map[string][]string{
+	"integer": {"text", "json"},
+	"text":    {"integer", "blob", "json", "uuid"},
+	"blob":    {},
+	"uuid":    {"text"},
+}
+
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case int:
+		return "integer"
+	case string:
+		return "text"
+	case []byte:
+		return "blob"
+	case UUID:
+		return "uuid"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case EnumValue:
+		return value.(EnumValue).Type.Name
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
+
+// castValue converts a value into the given column type, failing when the
+// value has no representation in that type
+func castValue(value interface{}, columnType string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if elementType, ok := arrayElementType(columnType); ok {
+		return mapArray(value, columnType, func(element interface{}) (interface{}, error) {
+			return castValue(element, elementType)
+		})
+	}
+	switch columnType {
+	case "integer":
+		switch value := value.(type) {
+		case int:
+			return integerValue(value)
+		case string:
+			integer, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid input for type integer: '%s'", value)
+			}
+			return integerValue(integer)
+		}
+	case "text":
+		return interfaceToString(value), nil
+	case "blob":
+		switch value := value.(type) {
+		case []byte:
+			return value, nil
+		case string:
+			return []byte(value), nil
+		case UUID:
+			return value[:], nil
+		}
+	case "json":
+		switch value := value.(type) {
+		case int:
+			return strconv.Itoa(value), nil
+		case string:
+			document, err := compactJson(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input for type json: %s", err.Error())
+			}
+			return document, nil
+		}
+	case "uuid":
+		switch value := value.(type) {
+		case UUID:
+			return value, nil
+		case string:
+			return ParseUUID(value)
+		case []byte:
+			var uuid UUID
+			if len(value) != len(uuid) {
+				return nil, fmt.Errorf("invalid input for type uuid: %s", interfaceToString(value))
+			}
+			copy(uuid[:], value)
+			return uuid, nil
+		}
+	default:
+		return nil, fmt.Errorf("type %s does not exist", columnType)
+	}
+	return nil, fmt.Errorf("cannot cast %s to %s", valueType(value), columnType)
+}
+
+// integerValue checks an integer fits into the 4 bytes integers are stored in
+func integerValue(value int) (interface{}, error) {
+	if value < math.MinInt32 || value > math.MaxInt32 {
+		return nil, errors.New("integer out of range")
+	}
+	return value, nil
+}
+
+// coerceValue implicitly converts a value into the given column type
+func coerceValue(value interface{}, columnType string) (interface{}, error) {
+	sourceType := valueType(value)
+	if value == nil || sourceType == columnType {
+		// Integers are computed with a wider type than the one they are
+		// stored with
+		if integer, ok := value.(int); ok {
+			return integerValue(integer)
+		}
+		return value, nil
+	}
+	if elementType, ok := arrayElementType(columnType); ok {
+		return mapArray(value, columnType, func(element interface{}) (interface{}, error) {
+			return coerceValue(element, elementType)
+		})
+	}
+	if !slices.Contains(implicitCoercions[sourceType], columnType) {
+		return nil, fmt.Errorf("cannot use %s value %s as %s", sourceType, interfaceToString(value), columnType)
+	}
+	return castValue(value, columnType)
+}
+
+// canCoerceColumn tells whether values of a type can be implicitly converted
+// into the type of a column. Text is converted into enums by their labels
+func canCoerceColumn(column ColumnDefinition, sourceType string) bool {
+	if column.Enum != nil && sourceType == "text" {
+		return true
+	}
+	return canCoerceType(sourceType, column.Type)
+}
+
+func canCoerceType(sourceType string, columnType string) bool {
+	if sourceType == columnType || sourceType == "null" {
+		return true
+	}
+	sourceElementType, sourceIsArray := arrayElementType(sourceType)
+	elementType, isArray := arrayElementType(columnType)
+	if sourceIsArray || isArray {
+		return sourceIsArray && isArray && canCoerceType(sourceElementType, elementType)
+	}
+	return slices.Contains(implicitCoercions[sourceType], columnType)
+}
+
+// coerceOperands converts two operands into a common type, so they can be
+// compared. When only one operand is text, it is converted into the type of
+// the other one
+func coerceOperands(a interface{}, b interface{}) (interface{}, interface{}, error) {
+	aType, bType := valueType(a), valueType(b)
+	if aType == bType {
+		return a, b, nil
+	}
+	var err error
+	switch {
+	case aType == "text":
+		a, err = coerceValueLike(a, b)
+	case bType == "text":
+		b, err = coerceValueLike(b, a)
+	default:
+		err = fmt.Errorf("cannot compare %s with %s", aType, bType)
+	}
+	return a, b, err
+}
+
+// coerceValueLike implicitly converts a value into the type of another one.
+// Text is converted into enums by looking up its label in the enum type
+func coerceValueLike(value interface{}, like interface{}) (interface{}, error) {
+	if enumValue, ok := like.(EnumValue); ok {
+		return enumValue.Type.Value(value)
+	}
+	return coerceValue(value, valueType(like))
+}
+
+// mapArray converts each element of an array value into an array of the given
+// column type
+func mapArray(value interface{}, columnType string, convert func(interface{}) (interface{}, error)) (interface{}, error) {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot use %s value %s as %s", valueType(value), interfaceToString(value), columnType)
+	}
+	converted := make([]interface{}, len(elements))
+	for i := range elements {
+		element, err := convert(elements[i])
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = element
+	}
+	return converted, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestCasts(t *testing.T) {
+	setup := []string{
+		"create table t (id integer, s text)",
+		"insert into t (id, s) values (1, '42')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "explicit casts",
+			query: "select cast(s as integer), id::text, '{\"a\":1}'::json from t",
+			want:  [][]string{{"42", "1", `{"a":1}`}},
+		},
+		{
+			name:  "text is converted when compared with integers",
+			query: "select id from t where id = '1'",
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:      "text is coerced into integer columns",
+			statement: "insert into t (id, s) values ('2', 'x')",
+			query:     "select id from t where s = 'x'",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "values that cannot be converted",
+			statement: "insert into t (id, s) values ('abc', 'x')",
+			wantErr:   "invalid input for type integer: 'abc'",
+		},
+		{
+			name:      "comparisons between unrelated types",
+			statement: "select id from t where id = x'00'",
+			wantErr:   "cannot compare integer with blob",
+		},
+		{
+			name:      "bounds of integers are stored",
+			statement: "insert into t (id, s) values (2147483647, '-2147483648')",
+			query:     "select id, s::integer from t where s = '-2147483648'",
+			want:      [][]string{{"2147483647", "-2147483648"}},
+		},
+		{
+			name:      "integers out of range are not stored",
+			statement: "insert into t (id, s) values (3000000000, 'x')",
+			wantErr:   "integer out of range",
+			query:     "select count() from t",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "text out of range is not coerced",
+			statement: "insert into t (id, s) values ('-3000000000', 'x')",
+			wantErr:   "integer out of range",
+		},
+		{
+			name:      "casts out of range",
+			statement: "select cast('2147483648' as integer) from t",
+			wantErr:   "integer out of range",
+		},
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+
+	"golang.org/x/exp/slices"
+)
+
+// conflictIndexes returns the unique indexes checked for conflicts by an on
+// conflict clause
+func (backend Backend) conflictIndexes(onConflict OnConflict) ([]IndexDefinition, error) {
+	tableName := backend.tableDefinition.Name
+	var indexes []IndexDefinition
+	for _, index := range backend.storageFor(tableName).tableIndexes(tableName) {
+		if !index.Unique {
+			continue
+		}
+		if onConflict.Columns == nil || sameColumns(index.Columns, *onConflict.Columns) {
+			indexes = append(indexes, index)
+		}
+	}
+	if onConflict.Columns != nil && len(indexes) == 0 {
+		return nil, errors.New("there is no unique constraint matching the on conflict specification")
+	}
+	return indexes, nil
+}
+
+// resolveConflicts splits rows about to be inserted into the rows without a
+// conflict, and the rows conflicting with an existing row. Conflicting rows
+// are returned by the key they have in the index of the conflict, and are
+// dropped for 'do nothing'
+func (backend Backend) resolveConflicts(rows []Row, onConflict OnConflict) ([]Row, map[string]Row, error) {
+	tableName := backend.tableDefinition.Name
+	indexes, err := backend.conflictIndexes(onConflict)
+	if err != nil {
+		return nil, nil, err
+	}
+	existingKeys := make([]map[string]bool, len(indexes))
+	insertedKeys := make([]map[string]bool, len(indexes))
+	for i, index := range indexes {
+		if existingKeys[i], err = backend.storageFor(tableName).indexKeys(index); err != nil {
+			return nil, nil, err
+		}
+		insertedKeys[i] = make(map[string]bool)
+	}
+
+	var inserted []Row
+	conflicts := make(map[string]Row)
+	for _, row := range rows {
+		keys := make([]string, len(indexes))
+		indexed := make([]bool, len(indexes))
+		conflict := false
+		for i, index := range indexes {
+			if keys[i], indexed[i], err = indexKey(index, backend.tableDefinition, row.Values); err != nil {
+				return nil, nil, err
+			}
+			if !indexed[i] {
+				continue
+			}
+			// An existing row may only be updated once, whether it was there
+			// before the insert or inserted by one of the previous rows
+			_, updated := conflicts[keys[i]]
+			if onConflict.Set != nil && (insertedKeys[i][keys[i]] || updated) {
+				return nil, nil, errors.New("on conflict do update command cannot affect row a second time")
+			}
+			if insertedKeys[i][keys[i]] || existingKeys[i][keys[i]] {
+				conflict = true
+				if onConflict.Set != nil {
+					conflicts[keys[i]] = row
+				}
+				break
+			}
+		}
+		if conflict {
+			continue
+		}
+		for i := range indexes {
+			if indexed[i] {
+				insertedKeys[i][keys[i]] = true
+			}
+		}
+		inserted = append(inserted, row)
+	}
+	return inserted, conflicts, nil
+}
+
+// updateConflicts applies the assignments of an on conflict clause to the
+// existing rows conflicting with inserted rows, and returns the updated rows.
+// The inserted row is referenced as excluded in the assignments
+func (backend Backend) updateConflicts(conflicts map[string]Row, onConflict OnConflict) ([]Row, error) {
+	indexes, err := backend.conflictIndexes(onConflict)
+	if err != nil {
+		return nil, err
+	}
+	index := indexes[0]
+
+	return backend.changeRows(UpdateTrigger, func(row Row) (Row, bool, error) {
+		key, indexed, err := indexKey(index, backend.tableDefinition, row.Values)
+		if err != nil || !indexed {
+			return row, false, err
+		}
+		excluded, ok := conflicts[key]
+		if !ok {
+			return row, false, nil
+		}
+
+		// Assignments are bound to the conflicting row, which is why they are
+		// copied for each row. The existing row is referenced by the name of
+		// the table, with or without its schema, or by its alias
+		existing := row
+		rows := map[string]*Row{"excluded": &excluded}
+		if onConflict.Alias != "" {
+			rows[onConflict.Alias] = &existing
+		} else {
+			schema, name := splitName(backend.tableDefinition.Name)
+			rows[name] = &existing
+			rows[schema+"."+name] = &existing
+		}
+		assignments := make([]UpdateAssignment, len(*onConflict.Set))
+		for i, assignment := range *onConflict.Set {
+			assignment.Value = copyExpression(assignment.Value)
+			if err := bindRowReferences(&assignment.Value, backend.tableDefinition, rows); err != nil {
+				return row, false, err
+			}
+			assignments[i] = assignment
+		}
+		if onConflict.Where != (Expression{}) {
+			where := copyExpression(onConflict.Where)
+			if err := bindRowReferences(&where, backend.tableDefinition, rows); err != nil {
+				return row, false, err
+			}
+			backend.currentRow = row
+			matches, err := backend.evaluateExpression(where, "")
+			if err != nil || matches != true {
+				return row, false, err
+			}
+		}
+		updated, err := backend.assignValues(row, assignments)
+		return updated, err == nil, err
+	})
+}
+
+// sameColumns tells whether two lists hold the same columns, in any order
+func sameColumns(a []string, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+// newRow returns a row with a value for each column of the table, evaluating
+// the default of columns without a given value
+func (backend Backend) newRow(values map[string]interface{}) (Row, error) {
+	var row Row
+	for column := range values {
+		index, ok := backend.tableDefinition.ColumnIndexes[column]
+		if !ok {
+			return row, fmt.Errorf("column %s of relation %s does not exist", column, backend.tableDefinition.Name)
+		}
+		if backend.tableDefinition.Columns[index].Identity == IdentityAlways {
+			return row, fmt.Errorf("cannot insert a value into column %s, which is generated always", column)
+		}
+		if backend.tableDefinition.Columns[index].Generated != (Expression{}) {
+			return row, fmt.Errorf("cannot insert a value into column %s, which is a generated column", column)
+		}
+	}
+	for _, column := range backend.tableDefinition.Columns {
+		value, ok := values[column.Name]
+		if !ok && column.Default != (Expression{}) {
+			var err error
+			if value, err = backend.evaluateExpression(column.Default, ""); err != nil {
+				return row, err
+			}
+		}
+		value, err := coerceColumnValue(column, value)
+		if err != nil {
+			return row, err
+		}
+		row.Values = append(row.Values, RowValue{Column: column.Name, Value: value})
+	}
+	return backend.generateColumns(row)
+}
+
+// generateColumns computes the values of the generated columns of a row from
+// its other columns
+func (backend Backend) generateColumns(row Row) (Row, error) {
+	backend.currentRow = row
+	for i, column := range backend.tableDefinition.Columns {
+		if column.Generated == (Expression{}) {
+			continue
+		}
+		value, err := backend.evaluateExpression(column.Generated, "")
+		if err != nil {
+			return row, err
+		}
+		if row.Values[i].Value, err = coerceColumnValue(column, value); err != nil {
+			return row, err
+		}
+	}
+	return row, nil
+}
+
+// checkRow validates the not null and check constraints of a row
+func (backend Backend) checkRow(row Row) error {
+	tableName := backend.tableDefinition.Name
+	backend.currentRow = row
+	for i, column := range backend.tableDefinition.Columns {
+		if column.NotNull && row.Values[i].Value == nil {
+			return notNullViolation(tableName, column.Name)
+		}
+		if column.Check == (Expression{}) {
+			continue
+		}
+		// Checks evaluating to null are satisfied, as in standard SQL
+		result, err := backend.evaluateExpression(column.Check, "")
+		if err != nil {
+			return err
+		}
+		switch result {
+		case true, nil:
+		case false:
+			return ConstraintViolationError{
+				Constraint: checkName(tableName, column.Name),
+				Message: fmt.Sprintf(
+					"new row for relation %s violates check constraint %s",
+					tableName,
+					checkName(tableName, column.Name),
+				),
+			}
+		default:
+			return fmt.Errorf("check constraint %s must evaluate to a boolean", checkName(tableName, column.Name))
+		}
+	}
+	return nil
+}
+
+// validateColumnExpressions makes sure defaults don't reference any column,
+// and checks and generation expressions only reference columns of the table.
+// All of them are evaluated for a single row, and checks and generation
+// expressions must give the same result each time they are evaluated
+func validateColumnExpressions(column ColumnDefinition, columns []ColumnDefinition) error {
+	if len(expressionIdentifiers(column.Default)) > 0 {
+		return fmt.Errorf("cannot use column reference in default expression of column %s", column.Name)
+	}
+	if err := checkRowExpression(column.Default, "default expressions"); err != nil {
+		return err
+	}
+	for _, identifier := range expressionIdentifiers(column.Check) {
+		if !slices.ContainsFunc(columns, func(c ColumnDefinition) bool { return c.Name == identifier }) {
+			return fmt.Errorf("column %s referenced in check constraint does not exist", identifier)
+		}
+	}
+	if err := checkRowExpression(column.Check, "check constraints"); err != nil {
+		return err
+	}
+	if name, ok := findFunctionCall(column.Check, isVolatileFunction); ok {
+		return fmt.Errorf("volatile function %s is not allowed in check constraints", name)
+	}
+	if name, ok := findFunctionCall(column.Check, isSequenceFunction); ok {
+		return fmt.Errorf("sequence function %s is not allowed in check constraints", name)
+	}
+	if column.Generated == (Expression{}) {
+		return nil
+	}
+
+	if column.Default != (Expression{}) {
+		return fmt.Errorf("both default and generation expression specified for column %s", column.Name)
+	}
+	for _, identifier := range expressionIdentifiers(column.Generated) {
+		index := slices.IndexFunc(columns, func(c ColumnDefinition) bool { return c.Name == identifier })
+		if index == -1 {
+			return fmt.Errorf("column %s referenced in generation expression does not exist", identifier)
+		}
+		// Generated columns are computed in any order, so they cannot depend
+		// on each other
+		if columns[index].Generated != (Expression{}) {
+			return fmt.Errorf("cannot use generated column %s in column generation expression", identifier)
+		}
+	}
+	// Values of generated columns must only depend on their row
+	name, ok := findFunctionCall(column.Generated, func(name string) bool {
+		return isAggregateFunction(name) || isSetReturningFunction(name) || isSequenceFunction(name) || isVolatileFunction(name)
+	})
+	if ok {
+		return fmt.Errorf("function %s cannot be used in the generation expression of column %s", name, column.Name)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import "testing"
+
+func TestPrimaryKeyAndUnique(t *testing.T) {
+	setup := []string{
+		"create table p (id integer primary key, email text unique, name text)",
+		"insert into p (id, email, name) values (1, 'a', 'x')",
+		"insert into p (id, email, name) values (3, 'c', 'y')",
+		"create table q (a integer, b integer, primary key (a, b))",
+		"insert into q (a, b) values (1, 1)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "primary key",
+			statement: "insert into p (id, email, name) values (1, 'b', 'z')",
+			wantErr:   "duplicate key value violates unique constraint p_pkey: (id)=(1)",
+			query:     "select * from p",
+			want:      [][]string{{"1", "a", "x"}, {"3", "c", "y"}},
+		},
+		{
+			name:      "unique",
+			statement: "insert into p (id, email, name) values (2, 'a', 'z')",
+			wantErr:   "duplicate key value violates unique constraint p_email_key: (email)=(a)",
+			query:     "select * from p",
+			want:      [][]string{{"1", "a", "x"}, {"3", "c", "y"}},
+		},
+		{
+			name:      "unique on update",
+			statement: "update p set email = 'a' where id = 3",
+			wantErr:   "duplicate key value violates unique constraint p_email_key: (email)=(a)",
+			query:     "select * from p",
+			want:      [][]string{{"1", "a", "x"}, {"3", "c", "y"}},
+		},
+		{
+			name:      "updating a row keeps its own key",
+			statement: "update p set email = 'a' where id = 1",
+			query:     "select * from p",
+			want:      [][]string{{"1", "a", "x"}, {"3", "c", "y"}},
+		},
+		{
+			name:      "composite primary key",
+			statement: "insert into q (a, b) values (1, 2)",
+			query:     "select a, b from q",
+			want:      [][]string{{"1", "1"}, {"1", "2"}},
+		},
+		{
+			name:      "composite primary key violation",
+			statement: "insert into q (a, b) values (1, 1)",
+			wantErr:   "duplicate key value violates unique constraint q_pkey: (a, b)=(1, 1)",
+		},
+		{
+			name:      "several primary keys",
+			statement: "create table r (a integer primary key, b integer primary key)",
+			wantErr:   "multiple primary keys for table r are not allowed",
+		},
+	})
+}
+
+func TestNotNullDefaultAndCheck(t *testing.T) {
+	setup := []string{
+		"create table e (n integer not null, q integer check (q < 10), s text default 'x')",
+		"insert into e (n, q) values (1, 2)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "defaults fill missing values",
+			statement: "insert into e (n) values (2)",
+			query:     "select n, q, s from e",
+			want:      [][]string{{"1", "2", "x"}, {"2", "null", "x"}},
+		},
+		{
+			name:      "not null",
+			statement: "insert into e (q) values (2)",
+			wantErr:   "null value in column n of relation e violates not-null constraint",
+			query:     "select n from e",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "check on insert",
+			statement: "insert into e (n, q) values (2, 20)",
+			wantErr:   "new row for relation e violates check constraint e_q_check",
+			query:     "select n from e",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "check on update",
+			statement: "update e set q = 20",
+			wantErr:   "new row for relation e violates check constraint e_q_check",
+			query:     "select q from e",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "added columns fill existing rows with their default",
+			statement: "alter table e add column w integer default 6 check (w > 5)",
+			query:     "select n, w from e",
+			want:      [][]string{{"1", "6"}},
+		},
+		{
+			name:      "added columns check their default",
+			statement: "alter table e add column w integer default 3 check (w > 5)",
+			wantErr:   "new row for relation e violates check constraint e_w_check",
+		},
+		{
+			name:      "column references in defaults",
+			statement: "create table f (id integer default id)",
+			wantErr:   "cannot use column reference in default expression of column id",
+		},
+		{
+			name:      "unknown columns in checks",
+			statement: "create table f (id integer check (x > 0))",
+			wantErr:   "column x referenced in check constraint does not exist",
+		},
+		{
+			name:      "aggregate functions in checks",
+			statement: "create table f (id integer check (id > count()))",
+			wantErr:   "aggregate functions are not allowed in check constraints",
+		},
+		{
+			name:      "aggregate functions in defaults",
+			statement: "create table f (id integer default count())",
+			wantErr:   "aggregate functions are not allowed in default expressions",
+		},
+		{
+			name:      "set-returning functions in defaults",
+			statement: "create table f (id integer default unnest(array[1]))",
+			wantErr:   "set-returning functions are not allowed in default expressions",
+		},
+		{
+			name:      "volatile functions in checks",
+			statement: "create table f (id uuid check (id <> gen_random_uuid()))",
+			wantErr:   "volatile function gen_random_uuid is not allowed in check constraints",
+		},
+		{
+			name:      "volatile functions in defaults",
+			statement: "create table f (id uuid default gen_random_uuid())",
+		},
+		{
+			name:      "aggregate functions in added defaults",
+			statement: "alter table e add column z integer default count()",
+			wantErr:   "aggregate functions are not allowed in default expressions",
+			query:     "select n, q, s from e",
+			want:      [][]string{{"1", "2", "x"}},
+		},
+		{
+			name:      "set-returning functions in added checks",
+			statement: "alter table e add column z text check (z <> unnest(array['a']))",
+			wantErr:   "set-returning functions are not allowed in check constraints",
+		},
+	})
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	setup := []string{
+		"create table t (a integer, b text, c integer generated always as (length(b)) stored)",
+		"insert into t (a, b) values (1, 'abc')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "values are computed on insert",
+			query: "select a, b, c from t",
+			want:  [][]string{{"1", "abc", "3"}},
+		},
+		{
+			name:      "values are computed on update",
+			statement: "update t set b = 'abcdef'",
+			query:     "select a, b, c from t",
+			want:      [][]string{{"1", "abcdef", "6"}},
+		},
+		{
+			name:      "values cannot be inserted",
+			statement: "insert into t (a, b, c) values (2, 'x', 5)",
+			wantErr:   "cannot insert a value into column c, which is a generated column",
+		},
+		{
+			name:      "values cannot be updated",
+			statement: "update t set c = 3",
+			wantErr:   "column c can only be generated by its expression",
+		},
+		{
+			name:      "added columns are computed for existing rows",
+			statement: "alter table t add column d text generated always as (b) stored",
+			query:     "select a, d from t",
+			want:      [][]string{{"1", "abc"}},
+		},
+		{
+			name:      "columns used by generated columns cannot be dropped",
+			statement: "alter table t drop column b",
+			wantErr:   "cannot drop column b of table t because generated column c depends on it",
+		},
+		{
+			name:      "sequence functions",
+			statement: "create table u (a integer, g integer generated always as (nextval('s')) stored)",
+			wantErr:   "function nextval cannot be used in the generation expression of column g",
+		},
+		{
+			name:      "volatile functions",
+			statement: "create table u (a uuid, g uuid generated always as (gen_random_uuid()) stored)",
+			wantErr:   "function gen_random_uuid cannot be used in the generation expression of column g",
+		},
+		{
+			name:      "unknown columns",
+			statement: "create table u (a integer, g integer generated always as (x) stored)",
+			wantErr:   "column x referenced in generation expression does not exist",
+		},
+		{
+			name:      "generated columns referencing each other",
+			statement: "create table u (a integer, g integer generated always as (a) stored, h integer generated always as (g) stored)",
+			wantErr:   "cannot use generated column g in column generation expression",
+		},
+		{
+			name:      "generated columns with a default",
+			statement: "create table u (a integer, g integer default 1 generated always as (a) stored)",
+			wantErr:   "both default and generation expression specified for column g",
+		},
+	})
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func isAggregateFunction(name string) bool {
+	switch name {
+	case "count":
+		return true
+	}
+	return false
+}
+
+// isSetReturningFunction tells whether a function produces one row for each of
+// the values it returns
+func isSetReturningFunction(name string) bool {
+	switch name {
+	case "unnest", "generate_series":
+		return true
+	}
+	return false
+}
+
+// isVolatileFunction tells whether a function may return a different value
+// each time it is called with the same arguments
+func isVolatileFunction(name string) bool {
+	switch name {
+	case "gen_random_uuid", "now":
+		return true
+	}
+	return false
+}
+
+func callScalarFunction(name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "length":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("function %s expects 1 argument", name)
+		}
+		switch value := args[0].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return utf8.RuneCountInString(value), nil
+		case []byte:
+			return len(value), nil
+		}
+		return nil, fmt.Errorf("function %s does not accept %s", name, interfaceToString(args[0]))
+	case "substr":
+		if len(args) != 2 && len(args) != 3 {
+			return nil, fmt.Errorf("function %s expects 2 or 3 arguments", name)
+		}
+		// Null arguments make the result null
+		for _, arg := range args {
+			if arg == nil {
+				return nil, nil
+			}
+		}
+		start, ok := args[1].(int)
+		if !ok {
+			return nil, fmt.Errorf("function %s expects an integer start position", name)
+		}
+		count := -1
+		if len(args) == 3 {
+			if count, ok = args[2].(int); !ok || count < 0 {
+				return nil, fmt.Errorf("function %s expects a non-negative integer length", name)
+			}
+		}
+		switch value := args[0].(type) {
+		case string:
+			runes := []rune(value)
+			from, to := substrBounds(len(runes), start, count)
+			return string(runes[from:to]), nil
+		case []byte:
+			from, to := substrBounds(len(value), start, count)
+			return append([]byte{}, value[from:to]...), nil
+		}
+		return nil, fmt.Errorf("function %s does not accept %s", name, interfaceToString(args[0]))
+	case "upper", "lower":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("function %s expects 1 argument", name)
+		}
+		switch value := args[0].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			if name == "upper" {
+				return strings.ToUpper(value), nil
+			}
+			return strings.ToLower(value), nil
+		}
+		return nil, fmt.Errorf("function %s does not accept %s", name, interfaceToString(args[0]))
+	case "now":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("function %s expects no arguments", name)
+		}
+		// There is no timestamp type, so the current time is returned as text
+		return time.Now().UTC().Format("2006-01-02 15:04:05.000000+00"), nil
+	case "gen_random_uuid":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("function %s expects no arguments", name)
+		}
+		return NewRandomUUID()
+	case "array_length":
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("function %s expects 1 or 2 arguments", name)
+		}
+		if len(args) == 2 && args[1] != 1 {
+			return nil, fmt.Errorf("function %s only supports dimension 1", name)
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		elements, ok := args[0].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("function %s expects an array", name)
+		}
+		return len(elements), nil
+	case "unnest":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("function %s expects 1 argument", name)
+		}
+		if args[0] == nil {
+			return []interface{}{}, nil
+		}
+		if _, ok := args[0].([]interface{}); !ok {
+			return nil, fmt.Errorf("function %s expects an array", name)
+		}
+		return args[0], nil
+	case "generate_series":
+		series, err := seriesValues(args)
+		if err != nil {
+			return nil, err
+		}
+		values := []interface{}{}
+		for value := range series {
+			values = append(values, value)
+		}
+		return values, nil
+	case "json_extract":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("function %s expects 2 arguments", name)
+		}
+		path, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("function %s expects a text path", name)
+		}
+		steps, err := parseJsonPath(path)
+		if err != nil {
+			return nil, err
+		}
+		document, found, err := extractJsonPath(args[0], steps)
+		if err != nil || !found {
+			return nil, err
+		}
+		return jsonToValue(document), nil
+	}
+	return nil, fmt.Errorf("function %s does not exist", name)
+}
+
+// seriesValues returns the values of generate_series(), counting from its
+// start up to its stop by its step, which is 1 unless given. Series with a
+// null argument are empty
+func seriesValues(args []interface{}) (func(yield func(int) bool), error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, errors.New("function generate_series expects 2 or 3 arguments")
+	}
+	bounds := []int{0, 0, 1}
+	for i, arg := range args {
+		if arg == nil {
+			return func(yield func(int) bool) {}, nil
+		}
+		integer, ok := arg.(int)
+		if !ok {
+			return nil, errors.New("function generate_series expects integer arguments")
+		}
+		bounds[i] = integer
+	}
+	start, stop, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return nil, errors.New("step size cannot equal zero")
+	}
+	return func(yield func(int) bool) {
+		for value := start; (step > 0 && value <= stop) || (step < 0 && value >= stop); value += step {
+			if !yield(value) {
+				return
+			}
+		}
+	}, nil
+}
+
+// substrBounds converts the 1-based start position and optional count used by
+// substr() into slice bounds, clamped to the value's length
+func substrBounds(length int, start int, count int) (int, int) {
+	end := length + 1
+	if count >= 0 {
+		end = start + count
+	}
+	from, to := start, end
+	if from < 1 {
+		from = 1
+	}
+	if from > length+1 {
+		from = length + 1
+	}
+	if to > length+1 {
+		to = length + 1
+	}
+	if to < from {
+		to = from
+	}
+	return from - 1, to - 1
+}
+
+// isSequenceFunction tells whether a function reads or changes the state of a
+// sequence
+func isSequenceFunction(name string) bool {
+	switch name {
+	case "nextval", "currval", "setval":
+		return true
+	}
+	return false
+}
+
+func (backend Backend) callSequenceFunction(name string, args []interface{}) (interface{}, error) {
+	expected := 1
+	if name == "setval" {
+		expected = 2
+	}
+	if len(args) != expected {
+		return nil, fmt.Errorf("function %s expects %d arguments", name, expected)
+	}
+	sequenceName, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("function %s expects a sequence name", name)
+	}
+	sequenceName = backend.resolveName(sequenceName)
+
+	switch name {
+	case "nextval":
+		value, err := backend.storageFor(sequenceName).NextValue(sequenceName)
+		if err != nil {
+			return nil, err
+		}
+		backend.sequenceValues[sequenceName] = value
+		return value, nil
+	case "currval":
+		if _, err := backend.storageFor(sequenceName).GetSequence(sequenceName); err != nil {
+			return nil, err
+		}
+		value, ok := backend.sequenceValues[sequenceName]
+		if !ok {
+			return nil, fmt.Errorf("currval of sequence %s is not yet defined in this session", sequenceName)
+		}
+		return value, nil
+	case "setval":
+		value, ok := args[1].(int)
+		if !ok {
+			return nil, fmt.Errorf("function %s expects an integer value", name)
+		}
+		if err := backend.storageFor(sequenceName).SetValue(sequenceName, value); err != nil {
+			return nil, err
+		}
+		backend.sequenceValues[sequenceName] = value
+		return value, nil
+	}
+	return nil, fmt.Errorf("function %s does not exist", name)
+}
@@ -0,0 +1,301 @@
+package main
+
+import "testing"
+
+func TestInsertSelect(t *testing.T) {
+	setup := []string{
+		"create table t (id integer primary key, name text)",
+		"create table src (id text, n integer)",
+		"insert into src (id, n) values ('1', 1)",
+		"insert into src (id, n) values ('x', 2)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "rows of another table",
+			statement: "insert into t (id, name) select n, id from src",
+			query:     "select * from t",
+			want:      [][]string{{"1", "1"}, {"2", "x"}},
+		},
+		{
+			name:      "filtered rows",
+			statement: "insert into t (name, id) select id, n from src where n = 2",
+			query:     "select * from t",
+			want:      [][]string{{"2", "x"}},
+		},
+		{
+			name:      "text literals",
+			statement: "insert into t (id, name) select '5', 'y' from src where n = 1",
+			query:     "select * from t",
+			want:      [][]string{{"5", "y"}},
+		},
+		{
+			name:      "text columns",
+			statement: "insert into t (id, name) select id, id from src",
+			wantErr:   "column id is of type integer but expression is of type text",
+		},
+		{
+			name:      "too many items",
+			statement: "insert into t (id, name) select n, id, n from src",
+			wantErr:   "insert has more expressions than target columns",
+		},
+		{
+			name:      "too few items",
+			statement: "insert into t (id, name) select n from src",
+			wantErr:   "insert has more target columns than expressions",
+		},
+		{
+			name:      "rows of a failing insert",
+			statement: "insert into t (id, name) select 3, 'a' from src",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(3)",
+			query:     "select * from t",
+		},
+	})
+}
+
+func TestInsertSelectFromTheSameTable(t *testing.T) {
+	setup := []string{
+		"create table t (id integer, name text)",
+		"insert into t (id, name) values (1, 'a')",
+		"insert into t (id, name) values (2, 'b')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "inserted rows are not read again",
+			statement: "insert into t (id, name) select id, name from t",
+			query:     "select * from t",
+			want:      [][]string{{"1", "a"}, {"2", "b"}, {"1", "a"}, {"2", "b"}},
+		},
+	})
+}
+
+func TestInsertValuesLists(t *testing.T) {
+	setup := []string{
+		"create table t (id integer primary key, name text)",
+		"insert into t (id, name) values (1, 'a'), (2, 'b')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "rows of every list",
+			query: "select * from t",
+			want:  [][]string{{"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:      "duplicate keys within the batch",
+			statement: "insert into t (id, name) values (3, 'c'), (3, 'd')",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(3)",
+			query:     "select * from t where id = 3",
+		},
+		{
+			name:      "duplicate keys of existing rows",
+			statement: "insert into t (id, name) values (4, 'c'), (1, 'd')",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(1)",
+			query:     "select * from t where id = 4",
+		},
+		{
+			name:      "invalid values",
+			statement: "insert into t (id, name) values (5, 'c'), ('x', 'd')",
+			wantErr:   "invalid input for type integer: 'x'",
+			query:     "select * from t where id = 5",
+		},
+		{
+			name:      "lists of different lengths",
+			statement: "insert into t (id, name) values (6, 'c'), (7)",
+			wantErr:   "values lists must all be the same length",
+		},
+	})
+}
+
+func TestInsertValuesListsReferencingEachOther(t *testing.T) {
+	setup := []string{
+		"create table tree (id integer primary key, parent integer references tree (id))",
+		"insert into tree (id, parent) values (1, null)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "rows referencing later rows of the batch",
+			statement: "insert into tree (id, parent) values (2, 3), (3, 1)",
+			query:     "select * from tree",
+			want:      [][]string{{"1", "null"}, {"2", "3"}, {"3", "1"}},
+		},
+		{
+			name:      "rows referencing missing rows",
+			statement: "insert into tree (id, parent) values (2, 1), (3, 9)",
+			wantErr:   "key (parent)=(9) is not present in table tree",
+			query:     "select * from tree",
+			want:      [][]string{{"1", "null"}},
+		},
+	})
+}
+
+func TestInsertOnConflict(t *testing.T) {
+	setup := []string{
+		"create table u (id integer primary key, code text unique, n integer)",
+		"insert into u (id, code, n) values (1, 'a', 1)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "do nothing on any unique index",
+			statement: "insert into u (id, code, n) values (1, 'b', 2), (2, 'c', 3) on conflict do nothing",
+			query:     "select * from u",
+			want:      [][]string{{"1", "a", "1"}, {"2", "c", "3"}},
+		},
+		{
+			name:      "do nothing on other unique indexes",
+			statement: "insert into u (id, code, n) values (3, 'a', 2) on conflict (id) do nothing",
+			wantErr:   "duplicate key value violates unique constraint u_code_key: (code)=(a)",
+		},
+		{
+			name:      "do update",
+			statement: "insert into u (id, code, n) values (1, 'z', 9) on conflict (id) do update set n = excluded.n",
+			query:     "select * from u",
+			want:      [][]string{{"1", "a", "9"}},
+		},
+		{
+			name:      "rows updated twice",
+			statement: "insert into u (id, code, n) values (1, 'z', 9), (1, 'y', 8) on conflict (id) do update set n = excluded.n",
+			wantErr:   "on conflict do update command cannot affect row a second time",
+			query:     "select * from u",
+			want:      [][]string{{"1", "a", "1"}},
+		},
+		{
+			name:      "columns without a unique index",
+			statement: "insert into u (id, code, n) values (1, 'z', 9) on conflict (n) do nothing",
+			wantErr:   "there is no unique constraint matching the on conflict specification",
+		},
+		{
+			name:      "do update without columns",
+			statement: "insert into u (id, code, n) values (1, 'z', 9) on conflict do update set n = 1",
+			wantErr:   "on conflict do update requires conflict columns",
+		},
+	})
+}
+
+func TestInsertOnConflictReferences(t *testing.T) {
+	setup := []string{
+		"create table u (id integer primary key, n integer, m integer)",
+		"insert into u (id, n, m) values (1, 5, 8)",
+		"create schema s",
+		"create table s.v (id integer primary key, n integer)",
+		"insert into s.v (id, n) values (1, 1)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "table name",
+			statement: "insert into u (id, n, m) values (1, 7, 0) on conflict (id) do update set n = u.m where u.n = 5",
+			query:     "select * from u",
+			want:      [][]string{{"1", "8", "8"}},
+		},
+		{
+			name:      "table name in a where clause that does not match",
+			statement: "insert into u (id, n, m) values (1, 7, 0) on conflict (id) do update set n = excluded.n where u.n = 1",
+			query:     "select * from u",
+			want:      [][]string{{"1", "5", "8"}},
+		},
+		{
+			name:      "table name with its schema",
+			statement: "insert into u (id, n, m) values (1, 7, 0) on conflict (id) do update set n = public.u.m",
+			query:     "select * from u",
+			want:      [][]string{{"1", "8", "8"}},
+		},
+		{
+			name:      "table of another schema",
+			statement: "insert into s.v (id, n) values (1, 2) on conflict (id) do update set n = excluded.n where s.v.n = v.n",
+			query:     "select * from s.v",
+			want:      [][]string{{"1", "2"}},
+		},
+		{
+			name:      "alias",
+			statement: "insert into u as x (id, n, m) values (1, 7, 0) on conflict (id) do update set n = x.m where x.n = 5",
+			query:     "select * from u",
+			want:      [][]string{{"1", "8", "8"}},
+		},
+		{
+			name:      "table name after an alias",
+			statement: "insert into u as x (id, n, m) values (1, 7, 0) on conflict (id) do update set n = u.m",
+			wantErr:   "column u.m does not exist",
+		},
+		{
+			name:      "unknown columns",
+			statement: "insert into u (id, n, m) values (1, 7, 0) on conflict (id) do update set n = u.x",
+			wantErr:   "record u has no field x",
+		},
+		{
+			name:      "missing alias",
+			statement: "insert into u as (id, n, m) values (1, 7, 0)",
+			wantErr:   "expected alias after 'as'",
+		},
+	})
+}
+
+func TestInsertExpressions(t *testing.T) {
+	setup := []string{
+		"create table t (id integer primary key, name text, n integer default 7)",
+		"insert into t values (1, 'a', 1)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "values without a columns list",
+			statement: "insert into t values (2, 'b')",
+			query:     "select * from t where id = 2",
+			want:      [][]string{{"2", "b", "7"}},
+		},
+		{
+			name:      "expressions",
+			statement: "insert into t values (1 + 2, upper('c'), length('abc') * 2)",
+			query:     "select * from t where id = 3",
+			want:      [][]string{{"3", "C", "6"}},
+		},
+		{
+			name:      "too many values",
+			statement: "insert into t values (2, 'b', 3, 4)",
+			wantErr:   "insert has more expressions than target columns",
+		},
+		{
+			name:      "column references",
+			statement: "insert into t values (2, name)",
+			wantErr:   "column name does not exist",
+		},
+		{
+			name:      "aggregate functions",
+			statement: "insert into t values (count(), 'x')",
+			wantErr:   "aggregate functions are not allowed in values",
+		},
+		{
+			name:      "nested aggregate functions",
+			statement: "insert into t values (1 + count(), 'x')",
+			wantErr:   "aggregate functions are not allowed in values",
+		},
+		{
+			name:      "set-returning functions",
+			statement: "insert into t values (2, unnest('x'))",
+			wantErr:   "set-returning functions are not allowed in values",
+		},
+		{
+			name:      "empty values",
+			statement: "insert into t values (2, 'b',)",
+			wantErr:   "expected expression in values list",
+		},
+		{
+			name:      "missing commas",
+			statement: "insert into t values (2 'b')",
+			wantErr:   "expected ',' or ')' after value",
+		},
+		{
+			name:      "dangling operators",
+			statement: "insert into t values (2 +, 'b')",
+			wantErr:   "expected expression after operator '+'",
+		},
+		{
+			name:      "existing rows in conflict updates",
+			statement: "insert into t values (1, 'z', 2) on conflict (id) do update set n = t.n + excluded.n",
+			query:     "select * from t",
+			want:      [][]string{{"1", "a", "3"}},
+		},
+		{
+			name:      "aliases in conflict updates",
+			statement: "insert into t as u values (1, 'z', 2) on conflict (id) do update set n = u.n * 10 + excluded.n",
+			query:     "select * from t",
+			want:      [][]string{{"1", "a", "12"}},
+		},
+	})
+}
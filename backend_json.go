@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// compactJson validates a JSON document and removes insignificant whitespace
+// from it, so that equal documents are stored, grouped and compared alike
+func compactJson(document string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(document)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// extractJsonPath walks a JSON document through a list of object keys and
+// array indexes, returning the JSON text found at the end of the path. The
+// second return value is false when the path does not exist in the document
+func extractJsonPath(document interface{}, path []interface{}) (string, bool, error) {
+	if document == nil {
+		return "", false, nil
+	}
+	str, ok := document.(string)
+	if !ok {
+		return "", false, fmt.Errorf("cannot extract json path from %s", interfaceToString(document))
+	}
+	current := json.RawMessage(str)
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			var object map[string]json.RawMessage
+			if json.Unmarshal(current, &object) != nil {
+				return "", false, nil
+			}
+			if current, ok = object[step]; !ok {
+				return "", false, nil
+			}
+		case int:
+			var array []json.RawMessage
+			if json.Unmarshal(current, &array) != nil {
+				return "", false, nil
+			}
+			if step < 0 || step >= len(array) {
+				return "", false, nil
+			}
+			current = array[step]
+		default:
+			return "", false, fmt.Errorf("invalid json path element %s", interfaceToString(step))
+		}
+	}
+	result, err := compactJson(string(current))
+	if err != nil {
+		return "", false, err
+	}
+	return result, true, nil
+}
+
+// jsonToText unquotes JSON strings and leaves any other JSON value as is, as
+// done by the ->> operator
+func jsonToText(document string) interface{} {
+	// Unmarshalling null into a string succeeds, leaving it empty
+	if document == "null" {
+		return nil
+	}
+	var str string
+	if json.Unmarshal([]byte(document), &str) == nil {
+		return str
+	}
+	return document
+}
+
+// jsonToValue converts scalar JSON values into their column counterparts, as
+// done by json_extract(). Objects and arrays are kept as JSON text
+func jsonToValue(document string) interface{} {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(document))
+	decoder.UseNumber()
+	if decoder.Decode(&value) != nil {
+		return nil
+	}
+	switch value := value.(type) {
+	case json.Number:
+		if integer, err := strconv.Atoi(value.String()); err == nil {
+			return integer
+		}
+		return value.String()
+	case string:
+		return value
+	case nil:
+		return nil
+	}
+	return document
+}
+
+// parseJsonPath turns a path such as '$.a.b[0]' into its list of object keys
+// and array indexes
+func parseJsonPath(path string) ([]interface{}, error) {
+	var steps []interface{}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path %s must start with '$'", path)
+	}
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %s", path)
+			}
+			steps = append(steps, rest[1:end+1])
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid json path %s", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid json path %s", path)
+			}
+			steps = append(steps, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid json path %s", path)
+		}
+	}
+	return steps, nil
+}
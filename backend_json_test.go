@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestJsonColumns(t *testing.T) {
+	setup := []string{
+		"create table t (id integer, doc json)",
+		`insert into t (id, doc) values (1, '{"a": {"b": [1, "x", null]}, "n": null, "s": "str"}')`,
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "documents are stored compacted",
+			query: "select doc from t",
+			want:  [][]string{{`{"a":{"b":[1,"x",null]},"n":null,"s":"str"}`}},
+		},
+		{
+			name:  "-> extracts json",
+			query: "select doc -> 'a', doc -> 'a' -> 'b' -> 1, doc -> 's' from t",
+			want:  [][]string{{`{"b":[1,"x",null]}`, `"x"`, `"str"`}},
+		},
+		{
+			name:  "->> extracts text",
+			query: "select doc ->> 's', doc -> 'a' -> 'b' ->> 0 from t",
+			want:  [][]string{{"str", "1"}},
+		},
+		{
+			name:  "->> returns null for json null",
+			query: "select doc ->> 'n', doc -> 'n' from t",
+			want:  [][]string{{"null", "null"}},
+		},
+		{
+			name:  "missing keys are null",
+			query: "select doc -> 'missing', doc ->> 'missing', doc -> 'a' -> 'b' -> 5 from t",
+			want:  [][]string{{"null", "null", "null"}},
+		},
+		{
+			name:  "json_extract converts scalars",
+			query: "select json_extract(doc, '$.a.b[0]'), json_extract(doc, '$.s') from t where json_extract(doc, '$.a.b[0]') = 1",
+			want:  [][]string{{"1", "str"}},
+		},
+		{
+			name:      "invalid documents are rejected",
+			statement: "insert into t (id, doc) values (2, 'nope')",
+			wantErr:   "invalid input for type json",
+			query:     "select count() from t",
+			want:      [][]string{{"1"}},
+		},
+	})
+}
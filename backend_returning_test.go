@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReturning(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      [][]string
+		wantErr   string
+	}{
+		{
+			name:      "inserted rows",
+			statement: "insert into t (id, name) values (3, 'c'), (4, 'd') returning id, name",
+			want:      [][]string{{"3", "c"}, {"4", "d"}},
+		},
+		{
+			name:      "every column",
+			statement: "insert into t (id, name) values (3, 'c') returning *",
+			want:      [][]string{{"3", "c"}},
+		},
+		{
+			name:      "updated rows",
+			statement: "update t set name = 'z' where id = 2 returning id, name, length(name)",
+			want:      [][]string{{"2", "z", "1"}},
+		},
+		{
+			name:      "deleted rows",
+			statement: "delete from t where id = 1 returning *",
+			want:      [][]string{{"1", "a"}},
+		},
+		{
+			name:      "rows updated on conflict",
+			statement: "insert into t (id, name) values (1, 'c') on conflict (id) do update set name = excluded.name returning id, name",
+			want:      [][]string{{"1", "c"}},
+		},
+		{
+			name:      "no changed rows",
+			statement: "update t set name = 'z' where id = 9 returning id",
+		},
+		{
+			name:      "aggregate functions",
+			statement: "insert into t (id, name) values (3, 'c') returning count()",
+			wantErr:   "aggregate functions are not allowed in returning",
+		},
+		{
+			name:      "nested aggregate functions",
+			statement: "delete from t returning length(count())",
+			wantErr:   "aggregate functions are not allowed in returning",
+		},
+		{
+			name:      "aggregate functions in operations",
+			statement: "insert into t (id, name) values (3, 'c') returning count() + 1",
+			wantErr:   "aggregate functions are not allowed in returning",
+		},
+		{
+			name:      "operations",
+			statement: "insert into t (id, name) values (3, 'c') returning id + 1, length(name) * 2",
+			want:      [][]string{{"4", "2"}},
+		},
+		{
+			name:      "set-returning functions",
+			statement: "update t set name = 'z' returning unnest(name)",
+			wantErr:   "set-returning functions are not allowed in returning",
+		},
+		{
+			name:      "unknown columns",
+			statement: "insert into t (id, name) values (3, 'c') returning x",
+			wantErr:   "column x does not exist",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newTestBackend(t)
+			mustExec(t, backend,
+				"create table t (id integer primary key, name text)",
+				"insert into t (id, name) values (1, 'a'), (2, 'b')",
+			)
+			statement, err := parseStatement(test.statement)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := backend.run(statement)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got rows %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// resolveName returns the name under which the relation referenced by a name
+// is stored. Unqualified names are looked up in each schema of the search
+// path, and are kept as they are when no relation is found
+func (backend Backend) resolveName(name string) string {
+	if strings.Contains(name, ".") {
+		return relationName(splitName(name))
+	}
+	for _, schema := range backend.searchPath {
+		candidate := relationName(schema, name)
+		if exists, _ := backend.temporaryStorage.relationExists(candidate); exists {
+			return candidate
+		}
+	}
+	return name
+}
+
+// creationName returns the name under which a new relation is stored, which
+// is in the first existing schema of the search path unless a schema is given
+func (backend Backend) creationName(name string) (string, error) {
+	if strings.Contains(name, ".") {
+		schema, relation := splitName(name)
+		exists, err := backend.storage.schemaExists(schema)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return "", fmt.Errorf("schema %s does not exist", schema)
+		}
+		return relationName(schema, relation), nil
+	}
+	for _, schema := range backend.searchPath {
+		exists, err := backend.storage.schemaExists(schema)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return relationName(schema, name), nil
+		}
+	}
+	return "", errors.New("no schema has been selected to create in")
+}
+
+// resolveNames replaces the relation names of a statement with the names they
+// are stored under. Names in select statements are resolved as they run, as
+// they are also used by views
+func (backend Backend) resolveNames(statement Statement) (Statement, error) {
+	var err error
+	switch statement.Kind {
+	case CreateTableKind:
+		name := statement.CreateTable.Name
+		if !statement.CreateTable.Temporary {
+			if name, err = backend.creationName(name); err != nil {
+				return statement, err
+			}
+		} else if strings.Contains(name, ".") {
+			return statement, errors.New("cannot create temporary relation in non-temporary schema")
+		}
+		constraints := make([]TableConstraint, len(*statement.CreateTable.Constraints))
+		for i, constraint := range *statement.CreateTable.Constraints {
+			if constraint.References == statement.CreateTable.Name {
+				constraint.References = name
+			} else if constraint.Kind == ForeignKeyConstraint {
+				constraint.References = backend.resolveName(constraint.References)
+			}
+			constraints[i] = constraint
+		}
+		statement.CreateTable.Name = name
+		statement.CreateTable.Constraints = &constraints
+	case CreateSequenceKind:
+		statement.CreateSequence.Name, err = backend.creationName(statement.CreateSequence.Name)
+	case DropSequenceKind:
+		statement.DropSequence.Name = backend.resolveName(statement.DropSequence.Name)
+	case CreateViewKind:
+		statement.CreateView.Name, err = backend.creationName(statement.CreateView.Name)
+		statement.CreateView.Query = backend.qualifyQuery(statement.CreateView.Query)
+	case DropViewKind:
+		statement.DropView.Name = backend.resolveName(statement.DropView.Name)
+	case RefreshMaterializedViewKind:
+		statement.RefreshMaterializedView.Name = backend.resolveName(statement.RefreshMaterializedView.Name)
+	case DropTableKind:
+		statement.DropTable.Name = backend.resolveName(statement.DropTable.Name)
+	case TruncateTableKind:
+		statement.Truncate.Name = backend.resolveName(statement.Truncate.Name)
+	case AlterTableKind:
+		statement.AlterTable.Table = backend.resolveName(statement.AlterTable.Table)
+		// Renamed tables stay in their schema
+		if statement.AlterTable.Action == RenameTableAction {
+			if strings.Contains(statement.AlterTable.NewName, ".") {
+				return statement, errors.New("cannot move a table into another schema by renaming it")
+			}
+			schema, _ := splitName(statement.AlterTable.Table)
+			statement.AlterTable.NewName = relationName(schema, statement.AlterTable.NewName)
+		}
+	case InsertKind:
+		statement.Insert.Table = backend.resolveName(statement.Insert.Table)
+	case UpdateKind:
+		statement.Update.Table = backend.resolveName(statement.Update.Table)
+	case DeleteKind:
+		statement.Delete.Table = backend.resolveName(statement.Delete.Table)
+	case CreateTriggerKind:
+		statement.CreateTrigger.Table = backend.resolveName(statement.CreateTrigger.Table)
+	case DropTriggerKind:
+		statement.DropTrigger.Table = backend.resolveName(statement.DropTrigger.Table)
+	}
+	return statement, err
+}
+
+// qualifyQuery rewrites the table of a select statement with its schema, so
+// the query references the same relation whatever the search path is
+func (backend Backend) qualifyQuery(query string) string {
+	lexer := NewLexer()
+	tokens := lexer.Scan(query)
+
+	var text []string
+	for i, token := range tokens {
+		// Functions in from are not relations
+		function := i+1 < len(tokens) && tokens[i+1].Type == LeftParenthesis
+		if i > 0 && tokens[i-1].Type == Keyword && tokens[i-1].Value == "from" && token.Type == Identifier && !function {
+			schema, name := splitName(backend.resolveName(token.Value.(string)))
+			token.Text = schema + "." + name
+		}
+		text = append(text, token.Text)
+	}
+	return strings.Join(text, " ")
+}
+
+func (backend *Backend) runSet(statement SetStatement) error {
+	if statement.Name != "search_path" {
+		return fmt.Errorf("unrecognized configuration parameter %s", statement.Name)
+	}
+	backend.searchPath = *statement.Values
+	return nil
+}
+
+func (backend Backend) runShow(statement ShowStatement) ([][]string, error) {
+	if statement.Name != "search_path" {
+		return nil, fmt.Errorf("unrecognized configuration parameter %s", statement.Name)
+	}
+	return [][]string{{strings.Join(backend.searchPath, ", ")}}, nil
+}
+
+func (backend Backend) runCreateSchema(statement CreateSchemaStatement) error {
+	exists, err := backend.storage.schemaExists(statement.Name)
+	if err != nil || (exists && statement.IfNotExists) {
+		return err
+	}
+	return backend.storage.CreateSchema(statement.Name)
+}
+
+func (backend Backend) runDropSchema(statement DropSchemaStatement) error {
+	exists, err := backend.storage.schemaExists(statement.Name)
+	if err != nil || (!exists && statement.IfExists) {
+		return err
+	}
+	return backend.storage.DropSchema(statement.Name)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemas(t *testing.T) {
+	setup := []string{
+		"create schema a",
+		"create table a.t (id integer)",
+		"create table t (id integer)",
+		"insert into a.t (id) values (1)",
+		"insert into t (id) values (2)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "qualified names",
+			query: "select id from a.t",
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:  "unqualified names default to public",
+			query: "select id from public.t",
+			want:  [][]string{{"2"}},
+		},
+		{
+			name:      "names are looked up in the search path",
+			statement: "set search_path to a, public",
+			query:     "select id from t",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "relations are created in the first schema of the search path",
+			statement: "set search_path to a, public",
+			query:     "select table_name from dbms_columns where column_name = 'id'",
+			want:      [][]string{{"a.t"}, {"t"}},
+		},
+		{
+			name:      "unknown schemas",
+			statement: "create table b.x (id integer)",
+			wantErr:   "schema b does not exist",
+		},
+		{
+			name:      "duplicate schemas",
+			statement: "create schema a",
+			wantErr:   "schema a already exists",
+		},
+		{
+			name:      "existing schemas with if not exists",
+			statement: "create schema if not exists a",
+		},
+		{
+			name:      "schemas with relations cannot be dropped",
+			statement: "drop schema a",
+			wantErr:   "cannot drop schema a because relation a.t depends on it",
+		},
+		{
+			name:      "empty schemas",
+			statement: "drop table a.t",
+			query:     "select id from t",
+			want:      [][]string{{"2"}},
+		},
+	})
+}
+
+func TestSearchPath(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create schema a",
+		"set search_path to a, public",
+		"create table u (id integer)",
+		"insert into u (id) values (1)",
+	)
+	if got, want := query(t, backend, "select id from a.u"), [][]string{{"1"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+	mustExec(t, backend, "drop table a.u", "drop schema a")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectWithoutFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name:  "expressions",
+			query: "select 1 + 2, upper('a')",
+			want:  [][]string{{"3", "A"}},
+		},
+		{
+			name:  "filtered out row",
+			query: "select 1 where 1 = 2",
+		},
+		{
+			name:  "set-returning functions in from",
+			query: "select * from generate_series(1, 3)",
+			want:  [][]string{{"1"}, {"2"}, {"3"}},
+		},
+		{
+			name:  "columns of functions in from",
+			query: "select generate_series from generate_series(1, 5) where generate_series > 3",
+			want:  [][]string{{"4"}, {"5"}},
+		},
+		{
+			name:  "sorted rows of functions in from",
+			query: "select * from generate_series(1, 4) order by generate_series desc limit 2",
+			want:  [][]string{{"4"}, {"3"}},
+		},
+		{
+			name:  "aggregates of functions in from",
+			query: "select count() from generate_series(1, 4)",
+			want:  [][]string{{"4"}},
+		},
+		{
+			name:  "empty series",
+			query: "select * from generate_series(3, 1)",
+		},
+		{
+			name:    "unknown functions",
+			query:   "select * from nosuch(1)",
+			wantErr: "function nosuch does not exist",
+		},
+		{
+			name:    "wrong number of arguments",
+			query:   "select * from generate_series(1)",
+			wantErr: "function generate_series expects 2 or 3 arguments",
+		},
+		{
+			name:    "columns",
+			query:   "select x",
+			wantErr: "column x does not exist",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newTestBackend(t)
+			statement, err := parseStatement(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := backend.runSelect(statement.Select)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got rows %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import "errors"
+
+// selectDefinition returns the definition of what a select reads from. Rows
+// returned by a function have a single column named after it, while selects
+// without from read a row without any column
+func (backend Backend) selectDefinition(statement SelectStatement) (TableDefinition, error) {
+	if statement.Function != nil {
+		name := statement.Function.Name
+		column := ColumnDefinition{Name: name}
+		if name == "generate_series" {
+			column.Type = "integer"
+		}
+		return TableDefinition{
+			Name:          name,
+			Columns:       []ColumnDefinition{column},
+			ColumnIndexes: map[string]int{name: 0},
+		}, nil
+	}
+	if statement.Table == "" {
+		for _, item := range *statement.Items {
+			if item.Kind == IdentifierExpressionKind && item.Identifier == "*" {
+				return TableDefinition{}, errors.New("select * with no tables specified is not valid")
+			}
+		}
+		return TableDefinition{ColumnIndexes: make(map[string]int)}, nil
+	}
+	return backend.relationDefinition(statement.Table)
+}
+
+// selectRows iterates through the rows a select reads from
+func (backend Backend) selectRows(statement SelectStatement) (func(yield func(int, Row) bool), error) {
+	if statement.Function != nil {
+		return backend.functionRows(*statement.Function)
+	}
+	if statement.Table == "" {
+		return func(yield func(int, Row) bool) {
+			yield(0, Row{})
+		}, nil
+	}
+	return backend.relationRows(statement.Table)
+}
+
+// functionRows iterates through the rows returned by a function in from. Set
+// returning functions return a row for each of their values, and other
+// functions a single row. Series are generated as they are read
+func (backend Backend) functionRows(function FunctionCall) (func(yield func(int, Row) bool), error) {
+	if isAggregateFunction(function.Name) {
+		return nil, errors.New("aggregate functions are not allowed in functions in from")
+	}
+	// Arguments cannot reference columns, as there is no row to read them from
+	backend.tableDefinition = TableDefinition{}
+	backend.currentRow = Row{}
+
+	row := func(value interface{}) Row {
+		return Row{Values: []RowValue{{Column: function.Name, Value: value}}}
+	}
+	if function.Name == "generate_series" {
+		var args []interface{}
+		for _, param := range *function.Params {
+			arg, err := backend.evaluateExpression(param, "")
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		series, err := seriesValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(yield func(int, Row) bool) {
+			i := 0
+			for value := range series {
+				if !yield(i, row(value)) {
+					return
+				}
+				i++
+			}
+		}, nil
+	}
+
+	value, err := backend.evaluateExpression(Expression{Kind: FunctionCallExpressionKind, FunctionCall: function}, "")
+	if err != nil {
+		return nil, err
+	}
+	values := []interface{}{value}
+	if isSetReturningFunction(function.Name) {
+		values = value.([]interface{})
+	}
+	return func(yield func(int, Row) bool) {
+		for i, value := range values {
+			if !yield(i, row(value)) {
+				return
+			}
+		}
+	}, nil
+}
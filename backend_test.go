@@ -0,0 +1,258 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestBackend returns a backend storing its data in a directory of its own,
+// which is removed once the test is done
+func newTestBackend(t *testing.T) *Backend {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	backend, err := NewBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { backend.Close() })
+	return backend
+}
+
+func parseStatement(input string) (Statement, error) {
+	lexer := NewLexer()
+	parser := NewParser()
+	return parser.Parse(lexer.Scan(input))
+}
+
+// exec runs statements in order, stopping at the first error
+func exec(backend *Backend, statements ...string) error {
+	for _, input := range statements {
+		statement, err := parseStatement(input)
+		if err != nil {
+			return err
+		}
+		if err := backend.Run(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mustExec runs statements in order, failing the test at the first error
+func mustExec(t *testing.T, backend *Backend, statements ...string) {
+	t.Helper()
+	if err := exec(backend, statements...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// query runs a select statement, returning its rows as text
+func query(t *testing.T, backend *Backend, input string) [][]string {
+	t.Helper()
+	statement, err := parseStatement(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := backend.runSelect(statement.Select)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+// sqlTest runs a statement against a database holding the rows of its setup,
+// expecting it to fail with wantErr, or to succeed when wantErr is empty. The
+// rows of query are compared with want afterwards
+type sqlTest struct {
+	name      string
+	statement string
+	wantErr   string
+	query     string
+	want      [][]string
+}
+
+func runSQLTests(t *testing.T, setup []string, tests []sqlTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newTestBackend(t)
+			mustExec(t, backend, setup...)
+
+			if test.statement != "" {
+				err := exec(backend, test.statement)
+				switch {
+				case test.wantErr == "" && err != nil:
+					t.Fatalf("got error %v, want none", err)
+				case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+			}
+			if test.query == "" {
+				return
+			}
+			if got := query(t, backend, test.query); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got rows %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBlobColumns(t *testing.T) {
+	setup := []string{
+		"create table t (id integer, b blob)",
+		"insert into t (id, b) values (1, x'DEADbeef')",
+		"insert into t (id) values (2)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "hex literals are stored as bytes",
+			query: "select id, b from t",
+			want:  [][]string{{"1", `\xdeadbeef`}, {"2", "null"}},
+		},
+		{
+			name:  "blobs are compared by value",
+			query: "select id from t where b = x'deadbeef'",
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:  "length counts bytes",
+			query: "select length(b), length('héllo') from t",
+			want:  [][]string{{"4", "5"}, {"null", "5"}},
+		},
+		{
+			name:  "substr slices bytes",
+			query: "select substr(b, 2, 2), substr('hello', 2) from t",
+			want:  [][]string{{`\xadbe`, "ello"}, {"null", "ello"}},
+		},
+		{
+			name:      "invalid hex digits",
+			statement: "insert into t (id, b) values (3, x'zz')",
+			wantErr:   "expected expression in values list",
+			query:     "select count() from t",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "unterminated string",
+			statement: "insert into t (id, b) values (3, 'abc)",
+			wantErr:   "expected expression in values list",
+			query:     "select count() from t",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "row larger than a page",
+			statement: "insert into t (id, b) values (3, x'" + strings.Repeat("00", 17000) + "')",
+			wantErr:   "row is too big: size 17009, maximum size 16380",
+			query:     "select count() from t",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "row updated to be larger than a page",
+			statement: "update t set b = x'" + strings.Repeat("00", 17000) + "' where id = 1",
+			wantErr:   "row is too big: size 17009, maximum size 16380",
+			query:     "select id, length(b) from t where id = 1",
+			want:      [][]string{{"1", "4"}},
+		},
+	})
+}
+
+func TestArrayColumns(t *testing.T) {
+	setup := []string{
+		"create table t (id integer, tags text[], ns integer[])",
+		"insert into t (id, tags, ns) values (1, array['a', 'b'], array[1, 2, 3])",
+		"insert into t (id) values (2)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "arrays and their elements",
+			query: "select id, tags, ns, ns[2], ns[5], array_length(ns) from t",
+			want:  [][]string{{"1", "{a,b}", "{1,2,3}", "2", "null", "3"}, {"2", "null", "null", "null", "null", "null"}},
+		},
+		{
+			name:  "any",
+			query: "select id from t where 2 = any(ns)",
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:  "all",
+			query: "select id from t where 0 < all(ns)",
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:  "unnest returns a row for each element",
+			query: "select unnest(tags), id from t",
+			want:  [][]string{{"a", "1"}, {"b", "1"}},
+		},
+		{
+			name:      "elements are converted into the element type",
+			statement: "insert into t (id, ns) values (3, array['x'])",
+			wantErr:   "invalid input for type integer: 'x'",
+			query:     "select count() from t",
+			want:      [][]string{{"2"}},
+		},
+	})
+}
+
+func TestTemporaryTables(t *testing.T) {
+	setup := []string{
+		"create temporary table t (id integer primary key)",
+		"insert into t (id) values (1)",
+		"create table p (id integer)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "temporary tables are read like tables",
+			query: "select id from t",
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:      "constraints of temporary tables",
+			statement: "insert into t (id) values (1)",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(1)",
+		},
+		{
+			name:      "temporary tables share the namespace of tables",
+			statement: "create temp table p (id integer)",
+			wantErr:   "relation p already exists",
+		},
+		{
+			name:      "permanent tables cannot reference temporary tables",
+			statement: "create table r (id integer references t (id))",
+			wantErr:   "constraints on permanent tables may reference only permanent tables",
+		},
+		{
+			name:      "dropped temporary tables",
+			statement: "drop table t",
+			query:     "select id from p",
+		},
+	})
+}
+
+func TestTemporaryTablesEndWithTheSession(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create temp table t (id integer)",
+		"insert into t (id) values (1)",
+	)
+	if err := backend.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := exec(other, "select id from t"); err == nil {
+		t.Error("expected temporary tables not to outlive their session")
+	}
+	mustExec(t, other, "create table t (id integer)")
+}
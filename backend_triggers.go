@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxTriggerDepth limits how many triggers may fire from the body of another
+// trigger, so triggers changing each other's tables cannot loop forever
+const maxTriggerDepth = 32
+
+// RowChange holds the rows of a change that fires triggers. New is nil for
+// deletes, and Old is nil for inserts
+type RowChange struct {
+	New *Row
+	Old *Row
+}
+
+func (backend Backend) runCreateTrigger(statement CreateTriggerStatement) error {
+	if err := backend.tableOnlyError(statement.Table); err != nil {
+		return err
+	}
+	storage := backend.storageFor(statement.Table)
+	tableDefinition, err := storage.GetTableDefinition(statement.Table)
+	if err != nil {
+		return err
+	}
+	trigger := Trigger{
+		Name:   statement.Name,
+		Table:  statement.Table,
+		Timing: statement.Timing,
+		Event:  statement.Event,
+		Body:   *statement.Body,
+	}
+
+	// References to the new and old rows are checked by binding empty rows
+	row := Row{Values: make([]RowValue, len(tableDefinition.Columns))}
+	change := RowChange{New: &row, Old: &row}
+	switch trigger.Event {
+	case InsertTrigger:
+		change.Old = nil
+	case DeleteTrigger:
+		change.New = nil
+	}
+	statements, err := parseTriggerBody(trigger)
+	if err != nil {
+		return err
+	}
+	for i := range statements {
+		if err := bindTriggerRows(&statements[i], tableDefinition, change); err != nil {
+			return err
+		}
+	}
+	return storage.CreateTrigger(trigger)
+}
+
+func (backend Backend) runDropTrigger(statement DropTriggerStatement) error {
+	storage := backend.storageFor(statement.Table)
+	trigger, found, err := storage.GetTrigger(statement.Name)
+	if err != nil {
+		return err
+	}
+	if !found || trigger.Table != statement.Table {
+		if statement.IfExists {
+			return nil
+		}
+		return fmt.Errorf("trigger %s for table %s does not exist", statement.Name, statement.Table)
+	}
+	return storage.DropTrigger(statement.Name)
+}
+
+// triggers returns the triggers of a table firing at a timing of an event
+func (backend Backend) triggers(tableName string, timing TriggerTiming, event TriggerEvent) []Trigger {
+	var triggers []Trigger
+	for _, trigger := range backend.storageFor(tableName).tableTriggers(tableName) {
+		if trigger.Timing == timing && trigger.Event == event {
+			triggers = append(triggers, trigger)
+		}
+	}
+	return triggers
+}
+
+// fireTriggers runs the body of the triggers of a table for each changed row,
+// with its new and old rows bound into the statements
+func (backend Backend) fireTriggers(tableName string, timing TriggerTiming, event TriggerEvent, changes []RowChange) error {
+	triggers := backend.triggers(tableName, timing, event)
+	if len(triggers) == 0 || len(changes) == 0 {
+		return nil
+	}
+	if backend.triggerDepth >= maxTriggerDepth {
+		return errors.New("stack depth limit exceeded")
+	}
+	tableDefinition, err := backend.storageFor(tableName).GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		for _, trigger := range triggers {
+			statements, err := parseTriggerBody(trigger)
+			if err != nil {
+				return err
+			}
+			for _, statement := range statements {
+				if err := bindTriggerRows(&statement, tableDefinition, change); err != nil {
+					return err
+				}
+				body := backend
+				body.triggerDepth++
+				if err := body.Run(statement); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// rowChanges returns the changes that an update or delete would make to the
+// rows of a table, without writing them, so before triggers can fire for
+// each row. change returns the new values of a row, and false for rows that
+// are left as they are
+func (backend Backend) rowChanges(tableName string, event TriggerEvent, change func(Row) (Row, bool, error)) ([]RowChange, error) {
+	var changes []RowChange
+	for _, row := range backend.storageFor(tableName).TableRows(tableName) {
+		newRow, matches, err := change(row)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		oldRow := row
+		if event == DeleteTrigger {
+			changes = append(changes, RowChange{Old: &oldRow})
+		} else {
+			changes = append(changes, RowChange{New: &newRow, Old: &oldRow})
+		}
+	}
+	return changes, nil
+}
+
+// parseTriggerBody parses the statements of a trigger, which are stored as
+// text
+func parseTriggerBody(trigger Trigger) ([]Statement, error) {
+	var statements []Statement
+	for _, text := range trigger.Body {
+		lexer := NewLexer()
+		parser := NewParser()
+		statement, err := parser.Parse(lexer.Scan(text))
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// bindTriggerRows replaces references to columns of the new and old rows in a
+// statement, such as new.id, with their values
+func bindTriggerRows(statement *Statement, tableDefinition TableDefinition, change RowChange) error {
+	rows := map[string]*Row{"new": change.New, "old": change.Old}
+	for _, expression := range statementExpressions(statement) {
+		if err := bindRowReferences(expression, tableDefinition, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
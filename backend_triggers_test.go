@@ -0,0 +1,113 @@
+package main
+
+import "testing"
+
+func TestTriggers(t *testing.T) {
+	setup := []string{
+		"create table t (id integer primary key, name text)",
+		"create table audit (event text, id integer)",
+		"create trigger log_insert after insert on t for each row begin insert into audit (event, id) values ('insert', new.id); end",
+		"create trigger log_update before update on t for each row begin insert into audit (event, id) values ('update', old.id); end",
+		"create trigger log_delete after delete on t for each row begin insert into audit (event, id) values ('delete', old.id); end",
+		"insert into t (id, name) values (1, 'a')",
+		"insert into t (id, name) values (2, 'b')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "insert triggers",
+			query: "select event, id from audit",
+			want:  [][]string{{"insert", "1"}, {"insert", "2"}},
+		},
+		{
+			name:      "update triggers",
+			statement: "update t set name = 'c' where id = 1",
+			query:     "select event, id from audit where event = 'update'",
+			want:      [][]string{{"update", "1"}},
+		},
+		{
+			name:      "delete triggers",
+			statement: "delete from t where id = 2",
+			query:     "select event, id from audit where event = 'delete'",
+			want:      [][]string{{"delete", "2"}},
+		},
+		{
+			name:      "unknown fields",
+			statement: "create trigger bad after insert on t for each row begin insert into audit (event, id) values (new.x, 1); end",
+			wantErr:   "record new has no field x",
+		},
+		{
+			name:      "new rows of delete triggers",
+			statement: "create trigger bad after delete on t for each row begin insert into audit (event, id) values (new.name, 1); end",
+			wantErr:   "record new is not assigned",
+		},
+		{
+			name:      "duplicate triggers",
+			statement: "create trigger log_insert after insert on t for each row begin delete from audit; end",
+			wantErr:   "trigger log_insert already exists",
+		},
+		{
+			name:      "dropping missing triggers",
+			statement: "drop trigger log_insert on audit",
+			wantErr:   "trigger log_insert for table audit does not exist",
+		},
+		{
+			name:      "dropping missing triggers if they exist",
+			statement: "drop trigger if exists log_insert on audit",
+		},
+		{
+			name:      "triggers are dropped with their table",
+			statement: "drop table t",
+			query:     "select type, name from dbms_catalog",
+			want:      [][]string{{"table", "audit"}},
+		},
+	})
+}
+
+func TestFailingTriggersLeaveNoChanges(t *testing.T) {
+	setup := []string{
+		"create table t (id integer primary key, name text)",
+		"create table audit (event text, id integer)",
+		"insert into t (id, name) values (1, 'a')",
+		"insert into t (id, name) values (2, 'b')",
+		"create trigger log_insert before insert on t for each row begin insert into audit (event, id) values ('insert', new.id); end",
+		"create trigger log_update before update on t for each row begin insert into audit (event, id) values ('update', old.id); end",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "inserts rejected after before triggers",
+			statement: "insert into t (id, name) values (1, 'c')",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(1)",
+			query:     "select * from audit",
+		},
+		{
+			name:      "updates rejected after before triggers",
+			statement: "update t set id = 1 where id = 2",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(1)",
+			query:     "select * from audit",
+		},
+		{
+			name:      "rows of rejected updates",
+			statement: "update t set id = 1 where id = 2",
+			wantErr:   "duplicate key value violates unique constraint t_pkey: (id)=(1)",
+			query:     "select * from t",
+			want:      [][]string{{"1", "a"}, {"2", "b"}},
+		},
+	})
+
+	setup = append(setup, "create trigger recurse after insert on audit for each row begin insert into audit (event, id) values ('recurse', new.id); end")
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "rows of recursive after triggers",
+			statement: "insert into t (id, name) values (3, 'c')",
+			wantErr:   "stack depth limit exceeded",
+			query:     "select * from t",
+			want:      [][]string{{"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:      "changes of recursive after triggers",
+			statement: "update t set name = 'c'",
+			wantErr:   "stack depth limit exceeded",
+			query:     "select * from audit",
+		},
+	})
+}
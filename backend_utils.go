@@ -1,6 +1,13 @@
 package main
 
-import "strconv"
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 func expandSelectItems(items []Expression, table TableDefinition) []Expression {
 	var selectItems []Expression
@@ -19,52 +26,347 @@ func expandSelectItems(items []Expression, table TableDefinition) []Expression {
 	return selectItems
 }
 
-func evaluateAGtB(a interface{}, b interface{}) bool {
-	switch a.(type) {
-	case int:
-		return a.(int) > b.(int)
-	case string:
-		return a.(string) > b.(string)
+// selectItemNames names the columns of a result set after their expressions,
+// using ?column? when an expression has no obvious name
+func selectItemNames(items []Expression) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		for item.Kind == CastExpressionKind {
+			item = item.Cast.Expression
+		}
+		switch item.Kind {
+		case IdentifierExpressionKind:
+			names[i] = item.Identifier
+		case FunctionCallExpressionKind:
+			names[i] = item.FunctionCall.Name
+		default:
+			names[i] = "?column?"
+		}
 	}
-	return false
+	return names
 }
 
-func evaluateAGteB(a interface{}, b interface{}) bool {
-	switch a.(type) {
-	case int:
-		return a.(int) >= b.(int)
-	case string:
-		return a.(string) >= b.(string)
+// selectItemTypes returns the type of each select item that is known before
+// the query runs, or an empty string when it depends on the values of a row
+func selectItemTypes(items []Expression, table TableDefinition) []string {
+	types := make([]string, len(items))
+	for i, item := range items {
+		switch item.Kind {
+		case IdentifierExpressionKind:
+			if index, ok := table.ColumnIndexes[item.Identifier]; ok {
+				types[i] = table.Columns[index].Type
+			}
+		case LiteralExpressionKind:
+			types[i] = valueType(item.Literal)
+		case NullExpressionKind:
+			types[i] = "null"
+		case CastExpressionKind:
+			types[i] = item.Cast.Type
+		case BinaryExpressionKind:
+			if isArithmeticOperator(item.Binary.Operator) {
+				types[i] = "integer"
+			}
+		}
 	}
-	return false
+	return types
+}
+
+// bindRowReferences replaces references to the columns of named rows in an
+// expression, such as excluded.id, with their values. Names may be qualified
+// with a schema, as in public.t.id. Rows are nil for names that have no row in
+// the current statement
+func bindRowReferences(expression *Expression, tableDefinition TableDefinition, rows map[string]*Row) error {
+	var err error
+	walkExpression(expression, func(expression *Expression) {
+		if err != nil || expression.Kind != IdentifierExpressionKind {
+			return
+		}
+		separator := strings.LastIndex(expression.Identifier, ".")
+		if separator < 0 {
+			return
+		}
+		record, column := expression.Identifier[:separator], expression.Identifier[separator+1:]
+		row, named := rows[record]
+		if !named {
+			return
+		}
+		if row == nil {
+			err = fmt.Errorf("record %s is not assigned", record)
+			return
+		}
+		index, found := tableDefinition.ColumnIndexes[column]
+		if !found {
+			err = fmt.Errorf("record %s has no field %s", record, column)
+			return
+		}
+		*expression = Expression{Kind: LiteralExpressionKind, Literal: row.Values[index].Value}
+		if row.Values[index].Value == nil {
+			*expression = Expression{Kind: NullExpressionKind}
+		}
+	})
+	return err
 }
 
-func evaluateALtB(a interface{}, b interface{}) bool {
-	switch a.(type) {
+// compareValues orders a against b once both are coerced into a common type,
+// returning a negative number when a < b, zero when a = b, and a positive
+// number when a > b. Null values are ordered after any other value
+func compareValues(a interface{}, b interface{}) (int, error) {
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil:
+		return 1, nil
+	case b == nil:
+		return -1, nil
+	}
+	a, b, err := coerceOperands(a, b)
+	if err != nil {
+		return 0, err
+	}
+	switch a := a.(type) {
 	case int:
-		return a.(int) < b.(int)
+		b := b.(int)
+		switch {
+		case a < b:
+			return -1, nil
+		case a > b:
+			return 1, nil
+		}
+		return 0, nil
 	case string:
-		return a.(string) < b.(string)
+		return strings.Compare(a, b.(string)), nil
+	case []byte:
+		return bytes.Compare(a, b.([]byte)), nil
+	case UUID:
+		b := b.(UUID)
+		return bytes.Compare(a[:], b[:]), nil
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			comparison, err := compareValues(a[i], b[i])
+			if comparison != 0 || err != nil {
+				return comparison, err
+			}
+		}
+		return len(a) - len(b), nil
+	case EnumValue:
+		b := b.(EnumValue)
+		return a.Position - b.Position, nil
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0, nil
+		case a:
+			return 1, nil
+		}
+		return -1, nil
 	}
-	return false
+	return 0, fmt.Errorf("cannot compare values of type %s", valueType(a))
 }
 
-func evaluateALteB(a interface{}, b interface{}) bool {
-	switch a.(type) {
-	case int:
-		return a.(int) <= b.(int)
-	case string:
-		return a.(string) <= b.(string)
+// expandSetReturningItems turns a row whose items include set-returning
+// functions into one row for each value they return. Functions returning fewer
+// values than others are padded with nulls
+func expandSetReturningItems(row *SelectRow, items []Expression) []*SelectRow {
+	count := -1
+	for i, item := range items {
+		if item.Kind == FunctionCallExpressionKind && isSetReturningFunction(item.FunctionCall.Name) {
+			if n := len(row.Items[i].([]interface{})); n > count {
+				count = n
+			}
+		}
+	}
+	if count == -1 {
+		return []*SelectRow{row}
+	}
+
+	rows := make([]*SelectRow, count)
+	for j := range rows {
+		rows[j] = &SelectRow{OrderBy: row.OrderBy}
+		for i, item := range items {
+			value := row.Items[i]
+			if item.Kind == FunctionCallExpressionKind && isSetReturningFunction(item.FunctionCall.Name) {
+				elements := value.([]interface{})
+				value = nil
+				if j < len(elements) {
+					value = elements[j]
+				}
+			}
+			rows[j].Items = append(rows[j].Items, value)
+		}
+	}
+	return rows
+}
+
+func evaluateComparison(operator string, a interface{}, b interface{}) (interface{}, error) {
+	// Comparisons involving null values are neither true nor false
+	if a == nil || b == nil {
+		return nil, nil
+	}
+	switch operator {
+	case "=":
+		return evaluateAEqB(a, b)
+	case "<>":
+		equal, err := evaluateAEqB(a, b)
+		return !equal, err
+	case ">":
+		return evaluateAGtB(a, b)
+	case ">=":
+		return evaluateAGteB(a, b)
+	case "<":
+		return evaluateALtB(a, b)
+	case "<=":
+		return evaluateALteB(a, b)
+	}
+	return nil, fmt.Errorf("operator %s is not supported", operator)
+}
+
+// evaluateArithmetic applies an arithmetic operator to two integers. Text
+// operands are converted into integers, as they would be in a comparison
+func evaluateArithmetic(operator string, a interface{}, b interface{}) (interface{}, error) {
+	if a == nil || b == nil {
+		return nil, nil
+	}
+	a, err := coerceValue(a, "integer")
+	if err != nil {
+		return nil, err
+	}
+	b, err = coerceValue(b, "integer")
+	if err != nil {
+		return nil, err
+	}
+	x, y := a.(int), b.(int)
+	switch operator {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/", "%":
+		if y == 0 {
+			return nil, errors.New("division by zero")
+		}
+		if operator == "/" {
+			return x / y, nil
+		}
+		return x % y, nil
+	}
+	return nil, fmt.Errorf("operator %s is not supported", operator)
+}
+
+func isArithmeticOperator(operator string) bool {
+	switch operator {
+	case "+", "-", "*", "/", "%":
+		return true
 	}
 	return false
 }
 
+// evaluateQuantifiedComparison compares a against the elements of the array b,
+// matching when the comparison holds for any or for all of them
+func evaluateQuantifiedComparison(operator string, quantifier string, a interface{}, b interface{}) (interface{}, error) {
+	if b == nil {
+		return nil, nil
+	}
+	elements, ok := b.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s() expects an array, got %s", quantifier, valueType(b))
+	}
+	for _, element := range elements {
+		result, err := evaluateComparison(operator, a, element)
+		if err != nil {
+			return nil, err
+		}
+		if quantifier == "any" && result == true {
+			return true, nil
+		}
+		if quantifier == "all" && result != true {
+			return false, nil
+		}
+	}
+	return quantifier == "all", nil
+}
+
+// arrayElement returns the element found at a 1-based index of an array, or
+// null when the index is out of bounds
+func arrayElement(array interface{}, index interface{}) (interface{}, error) {
+	if array == nil || index == nil {
+		return nil, nil
+	}
+	elements, ok := array.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot subscript %s value", valueType(array))
+	}
+	index, err := coerceValue(index, "integer")
+	if err != nil {
+		return nil, err
+	}
+	if i := index.(int); i >= 1 && i <= len(elements) {
+		return elements[i-1], nil
+	}
+	return nil, nil
+}
+
+func evaluateAEqB(a interface{}, b interface{}) (bool, error) {
+	comparison, err := compareValues(a, b)
+	return comparison == 0, err
+}
+
+func evaluateAGtB(a interface{}, b interface{}) (bool, error) {
+	comparison, err := compareValues(a, b)
+	return comparison > 0, err
+}
+
+func evaluateAGteB(a interface{}, b interface{}) (bool, error) {
+	comparison, err := compareValues(a, b)
+	return comparison >= 0, err
+}
+
+func evaluateALtB(a interface{}, b interface{}) (bool, error) {
+	comparison, err := compareValues(a, b)
+	return comparison < 0, err
+}
+
+func evaluateALteB(a interface{}, b interface{}) (bool, error) {
+	comparison, err := compareValues(a, b)
+	return comparison <= 0, err
+}
+
 func interfaceToString(i interface{}) string {
 	switch i.(type) {
 	case string:
 		return i.(string)
 	case int:
 		return strconv.Itoa(i.(int))
+	case []byte:
+		return "\\x" + hex.EncodeToString(i.([]byte))
+	case UUID:
+		return i.(UUID).String()
+	case EnumValue:
+		return i.(EnumValue).String()
+	case bool:
+		return strconv.FormatBool(i.(bool))
+	case []interface{}:
+		elements := make([]string, len(i.([]interface{})))
+		for j, element := range i.([]interface{}) {
+			elements[j] = interfaceToString(element)
+		}
+		return "{" + strings.Join(elements, ",") + "}"
+	case nil:
+		return "null"
 	}
 	return "?"
 }
+
+// checkRowExpression makes sure an expression can be evaluated for a single
+// row, as aggregate and set-returning functions need a set of rows
+func checkRowExpression(expression Expression, clause string) error {
+	if _, ok := findFunctionCall(expression, isAggregateFunction); ok {
+		return fmt.Errorf("aggregate functions are not allowed in %s", clause)
+	}
+	if _, ok := findFunctionCall(expression, isSetReturningFunction); ok {
+		return fmt.Errorf("set-returning functions are not allowed in %s", clause)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+// relationDefinition returns the definition of a table or view. Views have
+// untyped columns, named after the items of their query, while materialized
+// views are read like tables
+func (backend Backend) relationDefinition(name string) (TableDefinition, error) {
+	if isSystemTable(name) {
+		return systemTableDefinition(name), nil
+	}
+	storage := backend.storageFor(name)
+	view, found, err := storage.GetView(name)
+	if err != nil {
+		return TableDefinition{}, err
+	}
+	if !found || view.Materialized {
+		return storage.GetTableDefinition(name)
+	}
+
+	tableDefinition := TableDefinition{Name: name, ColumnIndexes: make(map[string]int)}
+	for i, column := range view.Columns {
+		tableDefinition.Columns = append(tableDefinition.Columns, ColumnDefinition{Name: column})
+		tableDefinition.ColumnIndexes[column] = i
+	}
+	return tableDefinition, nil
+}
+
+// relationRows iterates through the rows of a table, or the result of running
+// the query of a view or building a system catalog table
+func (backend Backend) relationRows(name string) (func(yield func(int, Row) bool), error) {
+	if isSystemTable(name) {
+		return backend.systemTableRows(name)
+	}
+	storage := backend.storageFor(name)
+	view, found, err := storage.GetView(name)
+	if err != nil {
+		return nil, err
+	}
+	if !found || view.Materialized {
+		return storage.TableRows(name), nil
+	}
+
+	rows, err := backend.viewRows(view)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(int, Row) bool) {
+		for i, values := range rows {
+			if !yield(i, Row{Values: values}) {
+				return
+			}
+		}
+	}, nil
+}
+
+// viewColumns names the columns of a view after the items of its query,
+// unless other names are given
+func (backend Backend) viewColumns(statement CreateViewStatement, query SelectStatement) ([]string, error) {
+	// Identifiers are only evaluated against rows, so they are checked up front
+	// in case the query doesn't return any
+	tableDefinition, err := backend.selectDefinition(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, identifier := range selectIdentifiers(query, tableDefinition) {
+		if _, ok := tableDefinition.ColumnIndexes[identifier]; !ok {
+			return nil, fmt.Errorf("column %s does not exist", identifier)
+		}
+	}
+
+	// Run the query without returning any row, which validates it and expands
+	// its items
+	query.Limit = 0
+	query.Offset = -1
+	columns, _, err := backend.querySelect(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement.Columns != nil {
+		if len(*statement.Columns) > len(columns) {
+			return nil, fmt.Errorf("create view %s specifies more column names than columns", statement.Name)
+		}
+		copy(columns, *statement.Columns)
+	}
+	for i, column := range columns {
+		if slices.Contains(columns[:i], column) {
+			return nil, fmt.Errorf("column %s specified more than once", column)
+		}
+	}
+	return columns, nil
+}
+
+// viewRows runs the query of a view, returning its result as rows of the view.
+// Columns added to tables after the view was created are left out, as the
+// columns of a view are fixed when it is created
+func (backend Backend) viewRows(view View) ([][]RowValue, error) {
+	query, err := parseViewQuery(view)
+	if err != nil {
+		return nil, err
+	}
+	_, result, err := backend.querySelect(query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]RowValue, len(result))
+	for i, items := range result {
+		for j, column := range view.Columns {
+			rows[i] = append(rows[i], RowValue{Column: column, Value: items[j]})
+		}
+	}
+	return rows, nil
+}
+
+// materializedColumns types the columns of a materialized view after the
+// items of its query. Items without a known type, such as comparisons and
+// function calls, are typed after the values of the rows, or as text when
+// they have no non-null value
+func (backend Backend) materializedColumns(view View, rows [][]RowValue) ([]ColumnDefinition, error) {
+	query, err := parseViewQuery(view)
+	if err != nil {
+		return nil, err
+	}
+	source, err := backend.relationDefinition(backend.resolveName(query.Table))
+	if err != nil {
+		return nil, err
+	}
+	itemTypes := selectItemTypes(expandSelectItems(*query.Items, source), source)
+
+	columns := make([]ColumnDefinition, len(view.Columns))
+	for i, name := range view.Columns {
+		columns[i] = ColumnDefinition{Name: name, Type: "text"}
+		if itemType := itemTypes[i]; itemType != "" && itemType != "null" {
+			if columnType := columnTypeFromString(itemType); columnType != UnknownColumnType {
+				columns[i].Type = columnTypeToString(columnType)
+				continue
+			}
+			// Types other than the built-in ones are user-defined enums
+			enum, err := backend.storage.GetEnumType(itemType)
+			if err != nil {
+				return nil, err
+			}
+			columns[i].Type = enum.Name
+			columns[i].Enum = enum
+			continue
+		}
+		for _, row := range rows {
+			value := row[i].Value
+			if value == nil {
+				continue
+			}
+			switch value := value.(type) {
+			case bool:
+				return nil, fmt.Errorf("column %s of materialized view %s cannot be of type boolean", name, view.Name)
+			case EnumValue:
+				columns[i].Type = value.Type.Name
+				columns[i].Enum = value.Type
+			case []interface{}:
+				columns[i].Type = "text[]"
+				for _, element := range value {
+					if element != nil {
+						columns[i].Type = valueType(element) + "[]"
+						break
+					}
+				}
+			default:
+				columns[i].Type = valueType(value)
+			}
+			break
+		}
+	}
+	return columns, nil
+}
+
+// systemTableRows describes the relations of both the shared and temporary
+// storages in the rows of a system catalog table
+func (backend Backend) systemTableRows(name string) (func(yield func(int, Row) bool), error) {
+	var result [][]interface{}
+	for _, storage := range []Storage{backend.storage, backend.temporaryStorage} {
+		rows, err := storage.systemTableRows(name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rows...)
+	}
+
+	columns := systemTableColumns[name]
+	return func(yield func(int, Row) bool) {
+		for i, items := range result {
+			var row Row
+			for j, column := range columns {
+				row.Values = append(row.Values, RowValue{Column: column.Name, Value: items[j]})
+			}
+			if !yield(i, row) {
+				return
+			}
+		}
+	}, nil
+}
+
+// tableOnlyError returns an error when a relation is a view or a system
+// catalog table, which cannot be changed like a table
+func (backend Backend) tableOnlyError(name string) error {
+	if isSystemTable(name) {
+		return fmt.Errorf("%s is a read-only system catalog table", name)
+	}
+	_, found, err := backend.storageFor(name).GetView(name)
+	if err != nil || !found {
+		return err
+	}
+	return fmt.Errorf("%s is not a table", name)
+}
+
+// parseViewQuery parses the select statement stored as the query of a view
+func parseViewQuery(view View) (SelectStatement, error) {
+	lexer := NewLexer()
+	parser := NewParser()
+
+	statement, err := parser.Parse(lexer.Scan(view.Query))
+	if err != nil {
+		return SelectStatement{}, err
+	}
+	if statement.Kind != SelectKind {
+		return SelectStatement{}, fmt.Errorf("query of view %s is not a select statement", view.Name)
+	}
+	return statement.Select, nil
+}
+
+// dependentViewsError returns an error when views select from a relation
+func (backend Backend) dependentViewsError(kind string, name string, action string) error {
+	for _, view := range backend.views() {
+		query, err := parseViewQuery(view)
+		if err != nil {
+			return err
+		}
+		if backend.resolveName(query.Table) == name {
+			return fmt.Errorf("cannot %s %s %s because %s %s depends on it", action, kind, name, view.kind(), view.Name)
+		}
+	}
+	return nil
+}
+
+// dependentColumnViewsError returns an error when views reference a column of
+// a table, either by name or through *
+func (backend Backend) dependentColumnViewsError(tableName string, columnName string, action string) error {
+	tableDefinition, err := backend.storageFor(tableName).GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+	for _, view := range backend.views() {
+		query, err := parseViewQuery(view)
+		if err != nil {
+			return err
+		}
+		if backend.resolveName(query.Table) != tableName {
+			continue
+		}
+		if slices.Contains(selectIdentifiers(query, tableDefinition), columnName) {
+			return fmt.Errorf(
+				"cannot %s column %s of table %s because %s %s depends on it",
+				action,
+				columnName,
+				tableName,
+				view.kind(),
+				view.Name,
+			)
+		}
+	}
+	return nil
+}
+
+// selectIdentifiers returns the names of the columns referenced by a select
+// statement, including those selected through *
+func selectIdentifiers(statement SelectStatement, tableDefinition TableDefinition) []string {
+	identifiers := expressionIdentifiers(statement.Where)
+	identifiers = append(identifiers, expressionIdentifiers(statement.GroupBy)...)
+	identifiers = append(identifiers, expressionIdentifiers(statement.OrderBy.By)...)
+	for _, item := range expandSelectItems(*statement.Items, tableDefinition) {
+		identifiers = append(identifiers, expressionIdentifiers(item)...)
+	}
+	// The * in count(*) doesn't reference any column
+	return slices.DeleteFunc(identifiers, func(identifier string) bool { return identifier == "*" })
+}
+
+// views returns the definitions of all views, including temporary ones
+func (backend Backend) views() []View {
+	return append(backend.storage.views(), backend.temporaryStorage.views()...)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestViews(t *testing.T) {
+	setup := []string{
+		"create table t (id integer, name text)",
+		"insert into t (id, name) values (1, 'a')",
+		"insert into t (id, name) values (2, 'b')",
+		"create view v as select id, name from t where id > 1",
+		"create view w (x, y) as select id, length(name) from t",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "views run their query",
+			query: "select * from v",
+			want:  [][]string{{"2", "b"}},
+		},
+		{
+			name:  "views with column names",
+			query: "select x, y from w where x = 1",
+			want:  [][]string{{"1", "1"}},
+		},
+		{
+			name:      "views read rows written afterwards",
+			statement: "insert into t (id, name) values (3, 'c')",
+			query:     "select id from v",
+			want:      [][]string{{"2"}, {"3"}},
+		},
+		{
+			name:      "views of views",
+			statement: "create view vv as select id from v",
+			query:     "select id from vv",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "too many column names",
+			statement: "create view z (a, b) as select id from t",
+			wantErr:   "create view z specifies more column names than columns",
+		},
+		{
+			name:      "duplicate relations",
+			statement: "create view t as select id from t",
+			wantErr:   "relation t already exists",
+		},
+		{
+			name:      "tables used by views cannot be dropped",
+			statement: "drop table t",
+			wantErr:   "cannot drop table t because view v depends on it",
+		},
+		{
+			name:      "columns used by views cannot be dropped",
+			statement: "alter table t drop column name",
+			wantErr:   "cannot drop column name of table t because view v depends on it",
+		},
+		{
+			name:      "dropped views",
+			statement: "drop view v",
+			query:     "select x from w",
+			want:      [][]string{{"1"}, {"2"}},
+		},
+		{
+			name:      "dropping unknown views if they exist",
+			statement: "drop view if exists nope",
+		},
+	})
+}
+
+func TestViewsUsedByViewsCannotBeDropped(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create table t (id integer)",
+		"create view v as select id from t",
+		"create view vv as select id from v",
+	)
+	err := exec(backend, "drop view v")
+	if want := "cannot drop view v because view vv depends on it"; err == nil || err.Error() != want {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+	mustExec(t, backend, "drop view vv", "drop view v", "drop table t")
+}
+
+func TestMaterializedViews(t *testing.T) {
+	setup := []string{
+		"create type mood as enum ('a', 'b')",
+		"create table t (id integer, name text, m mood, n integer)",
+		"insert into t (id, name, m) values (1, 'x', 'b')",
+		"create materialized view mv as select id, name, m, n, '5'::integer, length(name) from t",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "materialized views store their result",
+			query: "select * from mv",
+			want:  [][]string{{"1", "x", "b", "null", "5", "1"}},
+		},
+		{
+			name:      "materialized views are not changed by writes to their tables",
+			statement: "insert into t (id, name, m, n) values (2, 'y', 'a', 7)",
+			query:     "select id from mv",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "materialized views cannot be written to",
+			statement: "insert into mv (id) values (3)",
+			wantErr:   "mv is not a table",
+		},
+		{
+			name:      "materialized views are not views",
+			statement: "drop view mv",
+			wantErr:   "mv is not a view",
+		},
+		{
+			name:      "dropped materialized views",
+			statement: "drop materialized view mv",
+			query:     "select id from t",
+			want:      [][]string{{"1"}},
+		},
+	})
+}
+
+func TestRefreshMaterializedView(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create type mood as enum ('a', 'b')",
+		"create table t (id integer, name text, m mood, n integer)",
+		"insert into t (id, name, m) values (1, 'x', 'b')",
+		"create materialized view mv as select id, name, m, n, '5'::integer, length(name) from t",
+		"insert into t (id, name, m, n) values (2, 'y', 'a', 7)",
+		"refresh materialized view mv",
+	)
+	want := [][]string{{"2", "y", "a", "7", "5", "1"}, {"1", "x", "b", "null", "5", "1"}}
+	if got := query(t, backend, "select * from mv order by m"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+
+	// Columns are typed after the query items, even when the rows the view
+	// was created with only hold nulls
+	tableDefinition, err := backend.storage.GetTableDefinition("mv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, column := range tableDefinition.Columns {
+		types = append(types, column.Type)
+	}
+	if want := []string{"integer", "text", "mood", "integer", "integer", "integer"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("got column types %v, want %v", types, want)
+	}
+}
@@ -35,6 +35,15 @@ func (wb *ByteStreamBuffer) WriteString(value string) {
 	wb.buffer.Write([]byte(value))
 }
 
+func (wb *ByteStreamBuffer) WriteBytes(value []byte) {
+	wb.WriteInt(len(value), SmallIntSize)
+	wb.buffer.Write(value)
+}
+
+func (wb *ByteStreamBuffer) WriteFixedBytes(value []byte) {
+	wb.buffer.Write(value)
+}
+
 func (wb *ByteStreamBuffer) ReadInt(length NumericTypeSize) int {
 	var value int
 	switch length {
@@ -54,6 +63,21 @@ func (wb *ByteStreamBuffer) ReadString() string {
 	return value
 }
 
+func (wb *ByteStreamBuffer) ReadBytes() []byte {
+	length := wb.ReadInt(SmallIntSize)
+	value := make([]byte, length)
+	copy(value, wb.buffer.Bytes()[wb.cursor:wb.cursor+length])
+	wb.cursor += length
+	return value
+}
+
+func (wb *ByteStreamBuffer) ReadFixedBytes(length int) []byte {
+	value := make([]byte, length)
+	copy(value, wb.buffer.Bytes()[wb.cursor:wb.cursor+length])
+	wb.cursor += length
+	return value
+}
+
 func (wb *ByteStreamBuffer) Clear() {
 	wb.buffer.Reset()
 	wb.cursor = 0
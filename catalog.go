@@ -0,0 +1,187 @@
+package main
+
+import "fmt"
+
+type CatalogEntryKind uint
+
+const (
+	TableCatalogEntry CatalogEntryKind = iota
+	TypeCatalogEntry
+	FormatCatalogEntry
+	IndexCatalogEntry
+	ForeignKeyCatalogEntry
+	SequenceCatalogEntry
+	ViewCatalogEntry
+	SchemaCatalogEntry
+	TriggerCatalogEntry
+)
+
+// CatalogPageOwner owns the pages catalog entries are written to once the
+// table definitions page is full. It is not a valid identifier, so no relation
+// can have its name
+const CatalogPageOwner = "#catalog"
+
+// catalogPages returns the pages holding catalog entries, starting with the
+// table definitions page
+func (s Storage) catalogPages() ([]int, error) {
+	pages, err := s.tablePages(CatalogPageOwner)
+	if err != nil {
+		return nil, err
+	}
+	return append([]int{TableDefinitionsIndex}, pages...), nil
+}
+
+// findCatalogEntry looks for an entry in the catalog pages, returning a buffer
+// positioned at the start of its contents along with their length
+func (s Storage) findCatalogEntry(kind CatalogEntryKind, name string) (ByteStreamBuffer, int, bool, error) {
+	pages, err := s.catalogPages()
+	if err != nil {
+		return ByteStreamBuffer{}, 0, false, err
+	}
+	for _, pageIndex := range pages {
+		buf, err := s.readPage(pageIndex)
+		if err != nil {
+			return buf, 0, false, err
+		}
+
+		pageLength := buf.ReadInt(IntSize)
+		for buf.Cursor() < pageLength {
+			entryKind := CatalogEntryKind(buf.ReadInt(SmallIntSize))
+			entryName := buf.ReadString()
+			length := buf.ReadInt(IntSize)
+			if entryKind == kind && entryName == name {
+				return buf, length, true, nil
+			}
+			buf.Skip(length)
+		}
+	}
+
+	return ByteStreamBuffer{}, 0, false, nil
+}
+
+// catalogEntries iterates through all entries of a kind, yielding their names
+// along with buffers positioned at the start of their contents
+func (s Storage) catalogEntries(kind CatalogEntryKind) func(yield func(string, ByteStreamBuffer) bool) {
+	return func(yield func(string, ByteStreamBuffer) bool) {
+		pages, err := s.catalogPages()
+		if err != nil {
+			return
+		}
+		for _, pageIndex := range pages {
+			buf, err := s.readPage(pageIndex)
+			if err != nil {
+				return
+			}
+
+			pageLength := buf.ReadInt(IntSize)
+			for buf.Cursor() < pageLength {
+				entryKind := CatalogEntryKind(buf.ReadInt(SmallIntSize))
+				entryName := buf.ReadString()
+				length := buf.ReadInt(IntSize)
+				if entryKind == kind {
+					contents := NewByteStreamBufferFrom(buf.Bytes()[buf.Cursor() : buf.Cursor()+length])
+					if !yield(entryName, contents) {
+						return
+					}
+				}
+				buf.Skip(length)
+			}
+		}
+	}
+}
+
+// relationExists tells whether a table, index, sequence or view with the given
+// name exists, as they share the same namespace along with system catalog
+// tables. Temporary relations share it with the relations of the shared storage
+func (s Storage) relationExists(name string) (bool, error) {
+	if isSystemTable(name) {
+		return true, nil
+	}
+	found, err := s.ownsRelation(name)
+	if err != nil || found || s.shared == nil {
+		return found, err
+	}
+	return s.shared.relationExists(name)
+}
+
+// ownsRelation tells whether a relation with the given name exists in the
+// catalog of this storage
+func (s Storage) ownsRelation(name string) (bool, error) {
+	kinds := []CatalogEntryKind{TableCatalogEntry, IndexCatalogEntry, SequenceCatalogEntry, ViewCatalogEntry}
+	for _, kind := range kinds {
+		_, _, found, err := s.findCatalogEntry(kind, name)
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// addCatalogEntry appends an entry into the first catalog page with enough
+// space left, adding a page to the catalog when all of them are full. Entries
+// are prefixed by their kind, name and the length of their contents
+func (s Storage) addCatalogEntry(kind CatalogEntryKind, name string, contents ByteStreamBuffer) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteInt(int(kind), SmallIntSize)
+	buf.WriteString(name)
+	buf.WriteInt(contents.Length(), IntSize)
+	buf.Concat(contents)
+	if buf.Length()+int(IntSize) > s.pageSize {
+		return fmt.Errorf("definition of %s is too large", name)
+	}
+
+	pages, err := s.catalogPages()
+	if err != nil {
+		return err
+	}
+	for _, pageIndex := range pages {
+		page, err := s.readPage(pageIndex)
+		if err != nil {
+			return err
+		}
+		if page.ReadInt(IntSize)+buf.Length() <= s.pageSize {
+			return s.appendToPage(buf.Bytes(), pageIndex)
+		}
+	}
+	pageIndex, err := s.createPage(CatalogPageOwner, true)
+	if err != nil {
+		return err
+	}
+	return s.appendToPage(buf.Bytes(), pageIndex)
+}
+
+// removeCatalogEntry rewrites the catalog page holding an entry without it,
+// returning false when the entry does not exist
+func (s Storage) removeCatalogEntry(kind CatalogEntryKind, name string) (bool, error) {
+	pages, err := s.catalogPages()
+	if err != nil {
+		return false, err
+	}
+	for _, pageIndex := range pages {
+		buf, err := s.readPage(pageIndex)
+		if err != nil {
+			return false, err
+		}
+
+		var found bool
+		contents := NewByteStreamBuffer()
+		pageLength := buf.ReadInt(IntSize)
+		for buf.Cursor() < pageLength {
+			start := buf.Cursor()
+			entryKind := CatalogEntryKind(buf.ReadInt(SmallIntSize))
+			entryName := buf.ReadString()
+			length := buf.ReadInt(IntSize)
+			buf.Skip(length)
+			if entryKind == kind && entryName == name {
+				found = true
+				continue
+			}
+			contents.WriteFixedBytes(buf.Bytes()[start:buf.Cursor()])
+		}
+
+		if found {
+			return true, s.writePage(pageIndex, contents.Bytes())
+		}
+	}
+	return false, nil
+}
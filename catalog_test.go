@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCatalogSpansPages(t *testing.T) {
+	backend := newTestBackend(t)
+	const tables = 500
+	for i := 0; i < tables; i++ {
+		mustExec(t, backend, fmt.Sprintf("create table t%d (id integer, name text, email text)", i))
+	}
+	if pages, _ := backend.storage.catalogPages(); len(pages) < 2 {
+		t.Fatal("expected the catalog to take more pages")
+	}
+
+	// Tables are found wherever their definition is stored, and rows can
+	// still be written once the catalog has grown
+	for _, name := range []string{"t0", fmt.Sprintf("t%d", tables-1)} {
+		mustExec(t, backend, fmt.Sprintf("insert into %s (id, name, email) values (1, 'a', 'b')", name))
+		got := query(t, backend, fmt.Sprintf("select id, name, email from %s", name))
+		if want := [][]string{{"1", "a", "b"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got rows %v in %s, want %v", got, name, want)
+		}
+	}
+	mustExec(t, backend, fmt.Sprintf("drop table t%d", tables-1))
+	if _, _, found, _ := backend.storage.findCatalogEntry(TableCatalogEntry, fmt.Sprintf("t%d", tables-1)); found {
+		t.Errorf("expected t%d to be removed from the catalog", tables-1)
+	}
+}
+
+func TestCatalogEntryTooLarge(t *testing.T) {
+	backend := newTestBackend(t)
+	labels := make([]string, 2000)
+	for i := range labels {
+		labels[i] = fmt.Sprintf("'label%d'", i)
+	}
+	err := exec(backend, "create type big as enum ("+strings.Join(labels, ", ")+")")
+	if err == nil || err.Error() != "definition of big is too large" {
+		t.Fatalf("got error %v", err)
+	}
+	if _, _, found, _ := backend.storage.findCatalogEntry(TypeCatalogEntry, "big"); found {
+		t.Error("expected big not to be stored")
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+type ConstraintViolationError struct {
+	Constraint string
+	Message    string
+}
+
+func (e ConstraintViolationError) Error() string {
+	return e.Message
+}
+
+func notNullViolation(tableName string, columnName string) error {
+	return ConstraintViolationError{
+		Constraint: tableName + "_" + columnName + "_not_null",
+		Message: fmt.Sprintf(
+			"null value in column %s of relation %s violates not-null constraint",
+			columnName,
+			tableName,
+		),
+	}
+}
+
+// checkName follows Postgres conventions to name column check constraints
+func checkName(tableName string, columnName string) string {
+	return tableName + "_" + columnName + "_check"
+}
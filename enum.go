@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+type EnumType struct {
+	Name   string
+	Labels []string
+}
+
+type EnumValue struct {
+	Type     *EnumType
+	Position int
+}
+
+// Value converts labels of the enum into values that are ordered by the
+// position in which they were declared
+func (enum *EnumType) Value(value interface{}) (EnumValue, error) {
+	switch value := value.(type) {
+	case EnumValue:
+		if value.Type.Name == enum.Name {
+			return value, nil
+		}
+	case string:
+		position := slices.Index(enum.Labels, value)
+		if position == -1 {
+			return EnumValue{}, fmt.Errorf("invalid input value for enum %s: '%s'", enum.Name, value)
+		}
+		return EnumValue{Type: enum, Position: position}, nil
+	}
+	return EnumValue{}, fmt.Errorf("cannot use %s value %s as %s", valueType(value), interfaceToString(value), enum.Name)
+}
+
+func (value EnumValue) String() string {
+	return value.Type.Labels[value.Position]
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestEnumTypes(t *testing.T) {
+	setup := []string{
+		"create type mood as enum ('sad', 'ok', 'happy')",
+		"create table t (id integer, m mood)",
+		"insert into t (id, m) values (1, 'happy')",
+		"insert into t (id, m) values (2, 'sad')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "values are sorted in declaration order",
+			query: "select id, m from t order by m",
+			want:  [][]string{{"2", "sad"}, {"1", "happy"}},
+		},
+		{
+			name:  "labels are compared with text",
+			query: "select id, m::text, 'ok'::mood from t where m = 'happy'",
+			want:  [][]string{{"1", "happy", "ok"}},
+		},
+		{
+			name:      "unknown labels",
+			statement: "insert into t (id, m) values (3, 'angry')",
+			wantErr:   "invalid input value for enum mood: 'angry'",
+			query:     "select count() from t",
+			want:      [][]string{{"2"}},
+		},
+		{
+			name:      "duplicate types",
+			statement: "create type mood as enum ('a')",
+			wantErr:   "type mood already exists",
+		},
+		{
+			name:      "unknown types",
+			statement: "create table u (id integer, m feeling)",
+			wantErr:   "type feeling does not exist",
+		},
+		{
+			name:      "arrays of enum types",
+			statement: "create table u (id integer, m mood[])",
+			wantErr:   "arrays of enum type mood are not supported",
+		},
+		{
+			name:      "casts into arrays of enum types",
+			statement: "select 'ok'::mood[] from t",
+			wantErr:   "arrays of enum type mood are not supported",
+		},
+	})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+type ForeignKeyDefinition struct {
+	Name              string
+	Table             string
+	Columns           []string
+	References        string
+	ReferencedColumns []string
+	OnDelete          ForeignKeyAction
+}
+
+// foreignKeyName follows Postgres conventions to name foreign keys
+func foreignKeyName(tableName string, columns []string) string {
+	return tableName + "_" + strings.Join(columns, "_") + "_fkey"
+}
+
+// writeForeignKeyDefinition replaces the definition of a foreign key in the
+// table definitions page
+func (s Storage) writeForeignKeyDefinition(foreignKey ForeignKeyDefinition) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteString(foreignKey.Table)
+	buf.WriteString(foreignKey.References)
+	buf.WriteInt(int(foreignKey.OnDelete), SmallIntSize)
+	buf.WriteInt(len(foreignKey.Columns), SmallIntSize)
+	for i := range foreignKey.Columns {
+		buf.WriteString(foreignKey.Columns[i])
+		buf.WriteString(foreignKey.ReferencedColumns[i])
+	}
+
+	if _, err := s.removeCatalogEntry(ForeignKeyCatalogEntry, foreignKey.Name); err != nil {
+		return err
+	}
+	return s.addCatalogEntry(ForeignKeyCatalogEntry, foreignKey.Name, buf)
+}
+
+// foreignKeys returns the definitions of all foreign keys matching a filter
+func (s Storage) foreignKeys(filter func(ForeignKeyDefinition) bool) []ForeignKeyDefinition {
+	var foreignKeys []ForeignKeyDefinition
+	for name, buf := range s.catalogEntries(ForeignKeyCatalogEntry) {
+		foreignKey := ForeignKeyDefinition{
+			Name:       name,
+			Table:      buf.ReadString(),
+			References: buf.ReadString(),
+			OnDelete:   ForeignKeyAction(buf.ReadInt(SmallIntSize)),
+		}
+		columns := buf.ReadInt(SmallIntSize)
+		for i := 0; i < columns; i++ {
+			foreignKey.Columns = append(foreignKey.Columns, buf.ReadString())
+			foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, buf.ReadString())
+		}
+		if filter(foreignKey) {
+			foreignKeys = append(foreignKeys, foreignKey)
+		}
+	}
+	return foreignKeys
+}
+
+// tableForeignKeys returns the foreign keys defined on a table
+func (s Storage) tableForeignKeys(tableName string) []ForeignKeyDefinition {
+	return s.foreignKeys(func(foreignKey ForeignKeyDefinition) bool {
+		return foreignKey.Table == tableName
+	})
+}
+
+// referencingForeignKeys returns the foreign keys referencing a table
+func (s Storage) referencingForeignKeys(tableName string) []ForeignKeyDefinition {
+	return s.foreignKeys(func(foreignKey ForeignKeyDefinition) bool {
+		return foreignKey.References == tableName
+	})
+}
+
+func (s Storage) dropForeignKey(foreignKey ForeignKeyDefinition) error {
+	_, err := s.removeCatalogEntry(ForeignKeyCatalogEntry, foreignKey.Name)
+	return err
+}
+
+// referencedIndex finds the unique index on the referenced columns of a
+// foreign key
+func referencedIndex(foreignKey ForeignKeyDefinition, indexes []IndexDefinition) (IndexDefinition, bool) {
+	for _, index := range indexes {
+		if len(index.Columns) != len(foreignKey.ReferencedColumns) {
+			continue
+		}
+		matches := true
+		for _, column := range foreignKey.ReferencedColumns {
+			if !slices.Contains(index.Columns, column) {
+				matches = false
+			}
+		}
+		if matches {
+			return index, true
+		}
+	}
+	return IndexDefinition{}, false
+}
+
+// newForeignKey validates a foreign key constraint against the referenced
+// table, given its definition and indexes
+func newForeignKey(
+	tableDefinition TableDefinition,
+	constraint TableConstraint,
+	referencedTable TableDefinition,
+	referencedIndexes []IndexDefinition,
+) (ForeignKeyDefinition, error) {
+	foreignKey := ForeignKeyDefinition{
+		Name:              foreignKeyName(tableDefinition.Name, constraint.Columns),
+		Table:             tableDefinition.Name,
+		Columns:           constraint.Columns,
+		References:        constraint.References,
+		ReferencedColumns: constraint.ReferencedColumns,
+		OnDelete:          constraint.OnDelete,
+	}
+
+	// Foreign keys without referenced columns reference the primary key
+	if len(foreignKey.ReferencedColumns) == 0 {
+		for _, index := range referencedIndexes {
+			if index.Primary {
+				foreignKey.ReferencedColumns = index.Columns
+			}
+		}
+		if len(foreignKey.ReferencedColumns) == 0 {
+			return foreignKey, fmt.Errorf("there is no primary key for referenced table %s", foreignKey.References)
+		}
+	}
+	if len(foreignKey.Columns) != len(foreignKey.ReferencedColumns) {
+		return foreignKey, fmt.Errorf("number of referencing and referenced columns for foreign key %s disagree", foreignKey.Name)
+	}
+	if _, ok := referencedIndex(foreignKey, referencedIndexes); !ok {
+		return foreignKey, fmt.Errorf(
+			"there is no unique constraint matching given keys for referenced table %s",
+			foreignKey.References,
+		)
+	}
+
+	for i, columnName := range foreignKey.Columns {
+		columnIndex, ok := tableDefinition.ColumnIndexes[columnName]
+		if !ok {
+			return foreignKey, fmt.Errorf("column %s referenced in foreign key constraint does not exist", columnName)
+		}
+		column := tableDefinition.Columns[columnIndex]
+		referenced := referencedTable.Columns[referencedTable.ColumnIndexes[foreignKey.ReferencedColumns[i]]]
+		if column.Type != referenced.Type {
+			return foreignKey, fmt.Errorf(
+				"foreign key constraint %s cannot be implemented: key columns %s and %s are of incompatible types: %s and %s",
+				foreignKey.Name,
+				column.Name,
+				referenced.Name,
+				column.Type,
+				referenced.Type,
+			)
+		}
+	}
+
+	return foreignKey, nil
+}
+
+// foreignKeyValue returns the key referenced by a row in the unique index of
+// the referenced table. Rows with null values don't reference any row, so the
+// second return value is false for them
+func foreignKeyValue(
+	foreignKey ForeignKeyDefinition,
+	referencedTable TableDefinition,
+	index IndexDefinition,
+	row Row,
+) (string, bool, error) {
+	var values []RowValue
+	for i, column := range foreignKey.Columns {
+		value := rowValue(row.Values, column)
+		if value == nil {
+			return "", false, nil
+		}
+		values = append(values, RowValue{Column: foreignKey.ReferencedColumns[i], Value: value})
+	}
+	return indexKey(index, referencedTable, values)
+}
+
+// checkForeignKey makes sure a row references an existing row of the
+// referenced table, given the keys of its unique index
+func checkForeignKey(
+	foreignKey ForeignKeyDefinition,
+	referencedTable TableDefinition,
+	index IndexDefinition,
+	row Row,
+	keys map[string]bool,
+) error {
+	key, ok, err := foreignKeyValue(foreignKey, referencedTable, index, row)
+	if err != nil || !ok || keys[key] {
+		return err
+	}
+
+	var formatted []string
+	for _, column := range foreignKey.Columns {
+		formatted = append(formatted, interfaceToString(rowValue(row.Values, column)))
+	}
+	return ConstraintViolationError{
+		Constraint: foreignKey.Name,
+		Message: fmt.Sprintf(
+			"insert or update on table %s violates foreign key constraint %s: key (%s)=(%s) is not present in table %s",
+			foreignKey.Table,
+			foreignKey.Name,
+			strings.Join(foreignKey.Columns, ", "),
+			strings.Join(formatted, ", "),
+			foreignKey.References,
+		),
+	}
+}
+
+// referencedTable returns the definition of the table referenced by a foreign
+// key, along with the unique index on the referenced columns
+func (s Storage) referencedTable(foreignKey ForeignKeyDefinition) (TableDefinition, IndexDefinition, error) {
+	tableDefinition, err := s.GetTableDefinition(foreignKey.References)
+	if err != nil {
+		return tableDefinition, IndexDefinition{}, err
+	}
+	index, ok := referencedIndex(foreignKey, s.tableIndexes(foreignKey.References))
+	if !ok {
+		return tableDefinition, index, fmt.Errorf("index referenced by foreign key %s does not exist", foreignKey.Name)
+	}
+	return tableDefinition, index, nil
+}
+
+// dependentForeignKeysError returns an error when a table is referenced by
+// foreign keys of other tables
+func (s Storage) dependentForeignKeysError(tableName string, action string) error {
+	for _, foreignKey := range s.referencingForeignKeys(tableName) {
+		if foreignKey.Table != tableName {
+			return fmt.Errorf(
+				"cannot %s table %s because foreign key %s on table %s depends on it",
+				action,
+				tableName,
+				foreignKey.Name,
+				foreignKey.Table,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestForeignKeys(t *testing.T) {
+	setup := []string{
+		"create table p (id integer primary key)",
+		"insert into p (id) values (1)",
+		"insert into p (id) values (2)",
+		"create table c (id integer primary key, pid integer references p (id))",
+		"insert into c (id, pid) values (1, 1)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "foreign key to a missing row",
+			statement: "insert into c (id, pid) values (2, 5)",
+			wantErr:   "insert or update on table c violates foreign key constraint c_pid_fkey: key (pid)=(5) is not present in table p",
+			query:     "select id from c",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "foreign key to an existing row",
+			statement: "insert into c (id, pid) values (2, 2)",
+			query:     "select id, pid from c",
+			want:      [][]string{{"1", "1"}, {"2", "2"}},
+		},
+		{
+			name:      "foreign key with a null value",
+			statement: "insert into c (id) values (2)",
+			query:     "select id, pid from c",
+			want:      [][]string{{"1", "1"}, {"2", "null"}},
+		},
+		{
+			name:      "foreign key updated to a missing row",
+			statement: "update c set pid = 5",
+			wantErr:   "insert or update on table c violates foreign key constraint c_pid_fkey: key (pid)=(5) is not present in table p",
+			query:     "select id, pid from c",
+			want:      [][]string{{"1", "1"}},
+		},
+		{
+			name:      "foreign key updated to an existing row",
+			statement: "update c set pid = 2",
+			query:     "select id, pid from c",
+			want:      [][]string{{"1", "2"}},
+		},
+		{
+			name:      "referenced columns must be a key",
+			statement: "create table d (id integer references c (pid))",
+			wantErr:   "there is no unique constraint matching given keys for referenced table c",
+		},
+		{
+			name:      "referenced columns of another type",
+			statement: "create table d (id text references p (id))",
+			wantErr:   "foreign key constraint d_id_fkey cannot be implemented: key columns id and id are of incompatible types: text and integer",
+		},
+		{
+			name:      "referenced tables cannot be dropped",
+			statement: "drop table p",
+			wantErr:   "cannot drop table p because foreign key c_pid_fkey on table c depends on it",
+		},
+	})
+}
+
+func TestForeignKeyActions(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    string
+		statement string
+		wantErr   string
+		// wantChildren holds the rows of the referencing tables once the
+		// statement has run
+		wantChildren      [][]string
+		wantGrandchildren [][]string
+	}{
+		{
+			name:              "cascade removes referencing rows",
+			action:            "on delete cascade",
+			statement:         "delete from parent where id = 1",
+			wantChildren:      [][]string{{"20", "2"}},
+			wantGrandchildren: [][]string{{"200", "20"}},
+		},
+		{
+			name:              "set null clears referencing columns",
+			action:            "on delete set null",
+			statement:         "delete from parent where id = 1",
+			wantChildren:      [][]string{{"10", "null"}, {"20", "2"}},
+			wantGrandchildren: [][]string{{"100", "10"}, {"200", "20"}},
+		},
+		{
+			name:      "restrict rejects the delete",
+			action:    "on delete restrict",
+			statement: "delete from parent where id = 1",
+			wantErr:   "update or delete on table parent violates foreign key constraint child_pid_fkey on table child",
+		},
+		{
+			name:      "no action rejects the delete",
+			action:    "",
+			statement: "delete from parent where id = 1",
+			wantErr:   "update or delete on table parent violates foreign key constraint child_pid_fkey on table child",
+		},
+		{
+			name:      "updated keys are not cascaded",
+			action:    "on delete cascade",
+			statement: "update parent set id = 3 where id = 1",
+			wantErr:   "update or delete on table parent violates foreign key constraint child_pid_fkey on table child",
+		},
+		{
+			name:              "unreferenced rows are deleted",
+			action:            "",
+			statement:         "delete from parent where id = 9",
+			wantChildren:      [][]string{{"10", "1"}, {"20", "2"}},
+			wantGrandchildren: [][]string{{"100", "10"}, {"200", "20"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newTestBackend(t)
+			// Grandchildren cascade from children, whatever the action of
+			// the children on their parent
+			mustExec(t, backend,
+				"create table parent (id integer primary key)",
+				"create table child (id integer primary key, pid integer references parent (id) "+test.action+")",
+				"create table grandchild (id integer primary key, cid integer references child (id) on delete cascade)",
+				"insert into parent (id) values (1)",
+				"insert into parent (id) values (2)",
+				"insert into parent (id) values (9)",
+				"insert into child (id, pid) values (10, 1)",
+				"insert into child (id, pid) values (20, 2)",
+				"insert into grandchild (id, cid) values (100, 10)",
+				"insert into grandchild (id, cid) values (200, 20)",
+			)
+			parents := query(t, backend, "select id from parent")
+
+			err := exec(backend, test.statement)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				if got := query(t, backend, "select id from parent"); !reflect.DeepEqual(got, parents) {
+					t.Errorf("got parents %v after a rejected statement, want %v", got, parents)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := query(t, backend, "select id, pid from child order by id"); !reflect.DeepEqual(got, test.wantChildren) {
+				t.Errorf("got children %v, want %v", got, test.wantChildren)
+			}
+			if got := query(t, backend, "select id, cid from grandchild order by id"); !reflect.DeepEqual(got, test.wantGrandchildren) {
+				t.Errorf("got grandchildren %v, want %v", got, test.wantGrandchildren)
+			}
+		})
+	}
+}
@@ -1,9 +1,10 @@
 module dbms
 
-go 1.18
+go 1.23
 
 require (
-	github.com/goombaio/namegenerator v0.0.0-20181006234301-989e774b106e // indirect
-	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
-	golang.org/x/exp v0.0.0-20240325151524-a685a6edb6d8 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7
+	golang.org/x/exp v0.0.0-20240325151524-a685a6edb6d8
 )
+
+require github.com/goombaio/namegenerator v0.0.0-20181006234301-989e774b106e // indirect
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+type IndexDefinition struct {
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+// indexName follows Postgres conventions to name the indexes backing primary
+// key and unique constraints
+func indexName(tableName string, constraint TableConstraint) string {
+	if constraint.Kind == PrimaryKeyConstraint {
+		return tableName + "_pkey"
+	}
+	return tableName + "_" + strings.Join(constraint.Columns, "_") + "_key"
+}
+
+func (s Storage) createIndex(index IndexDefinition) error {
+	exists, err := s.relationExists(index.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("relation %s already exists", index.Name)
+	}
+	return s.writeIndexDefinition(index)
+}
+
+// writeIndexDefinition replaces the definition of an index in the table
+// definitions page
+func (s Storage) writeIndexDefinition(index IndexDefinition) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteString(index.Table)
+	buf.WriteInt(boolToInt(index.Unique), SmallIntSize)
+	buf.WriteInt(boolToInt(index.Primary), SmallIntSize)
+	buf.WriteInt(len(index.Columns), SmallIntSize)
+	for _, column := range index.Columns {
+		buf.WriteString(column)
+	}
+
+	if _, err := s.removeCatalogEntry(IndexCatalogEntry, index.Name); err != nil {
+		return err
+	}
+	return s.addCatalogEntry(IndexCatalogEntry, index.Name, buf)
+}
+
+// tableIndexes returns the definitions of all indexes of a table
+func (s Storage) tableIndexes(tableName string) []IndexDefinition {
+	var indexes []IndexDefinition
+	for name, buf := range s.catalogEntries(IndexCatalogEntry) {
+		index := IndexDefinition{Name: name, Table: buf.ReadString()}
+		if index.Table != tableName {
+			continue
+		}
+		index.Unique = buf.ReadInt(SmallIntSize) == 1
+		index.Primary = buf.ReadInt(SmallIntSize) == 1
+		columns := buf.ReadInt(SmallIntSize)
+		for i := 0; i < columns; i++ {
+			index.Columns = append(index.Columns, buf.ReadString())
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+func (s Storage) dropIndex(index IndexDefinition) error {
+	if _, err := s.removeCatalogEntry(IndexCatalogEntry, index.Name); err != nil {
+		return err
+	}
+	delete(s.indexCache, index.Name)
+	return s.releasePages(index.Name)
+}
+
+// indexKey encodes the values of the indexed columns of a row. Rows with null
+// values are not indexed, so the second return value is false for them
+func indexKey(index IndexDefinition, tableDefinition TableDefinition, values []RowValue) (string, bool, error) {
+	buf := NewByteStreamBuffer()
+	for _, columnName := range index.Columns {
+		column := tableDefinition.Columns[tableDefinition.ColumnIndexes[columnName]]
+		value := rowValue(values, columnName)
+		if value == nil {
+			if index.Primary {
+				return "", false, notNullViolation(tableDefinition.Name, columnName)
+			}
+			return "", false, nil
+		}
+		if err := writeColumnValue(&buf, column, value); err != nil {
+			return "", false, err
+		}
+	}
+	return string(buf.Bytes()), true, nil
+}
+
+// checkIndexKey returns the key of a row for a unique index, failing when the
+// key is already present in keys
+func checkIndexKey(index IndexDefinition, tableDefinition TableDefinition, values []RowValue, keys map[string]bool) (string, bool, error) {
+	key, ok, err := indexKey(index, tableDefinition, values)
+	if err != nil || !ok {
+		return key, ok, err
+	}
+	if index.Unique && keys[key] {
+		var formatted []string
+		for _, column := range index.Columns {
+			formatted = append(formatted, interfaceToString(rowValue(values, column)))
+		}
+		return key, ok, ConstraintViolationError{
+			Constraint: index.Name,
+			Message: fmt.Sprintf(
+				"duplicate key value violates unique constraint %s: (%s)=(%s)",
+				index.Name,
+				strings.Join(index.Columns, ", "),
+				strings.Join(formatted, ", "),
+			),
+		}
+	}
+	return key, ok, nil
+}
+
+// indexKeys loads the keys stored in an index. Keys are kept in memory after
+// being read, so lookups don't need to go through the index pages again
+func (s Storage) indexKeys(index IndexDefinition) (map[string]bool, error) {
+	if keys, ok := s.indexCache[index.Name]; ok {
+		return keys, nil
+	}
+
+	keys := make(map[string]bool)
+	pages, err := s.tablePages(index.Name)
+	if err != nil {
+		return keys, err
+	}
+	for _, pageIndex := range pages {
+		page, err := s.readPage(pageIndex)
+		if err != nil {
+			return keys, err
+		}
+		pageLength := page.ReadInt(IntSize)
+		for page.Cursor() < pageLength {
+			keys[string(page.ReadBytes())] = true
+		}
+	}
+
+	s.indexCache[index.Name] = keys
+	return keys, nil
+}
+
+// addIndexKeys appends keys into the pages of an index
+func (s Storage) addIndexKeys(index IndexDefinition, newKeys []string) error {
+	keys, err := s.indexKeys(index)
+	if err != nil {
+		return err
+	}
+
+	var entries [][]byte
+	for _, key := range newKeys {
+		buf := NewByteStreamBuffer()
+		buf.WriteBytes([]byte(key))
+		entries = append(entries, buf.Bytes())
+	}
+	if err := s.appendToTablePages(index.Name, entries); err != nil {
+		return err
+	}
+
+	for _, key := range newKeys {
+		keys[key] = true
+	}
+	return nil
+}
+
+// rebuildIndex replaces all keys stored in an index
+func (s Storage) rebuildIndex(index IndexDefinition, keys map[string]bool) error {
+	sortedKeys := maps.Keys(keys)
+	slices.Sort(sortedKeys)
+
+	var entries [][]byte
+	for _, key := range sortedKeys {
+		buf := NewByteStreamBuffer()
+		buf.WriteBytes([]byte(key))
+		entries = append(entries, buf.Bytes())
+	}
+	if err := s.writeRows(index.Name, entries); err != nil {
+		return err
+	}
+
+	s.indexCache[index.Name] = keys
+	return nil
+}
+
+func rowValue(values []RowValue, column string) interface{} {
+	for i := range values {
+		if values[i].Column == column {
+			return values[i].Value
+		}
+	}
+	return nil
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package main
+
+import "os"
+
+// pageJournal keeps the contents that pages had before the statement being
+// run changed them, so a failing statement can be undone
+type pageJournal struct {
+	active bool
+	// fileSize is the size of the data file when the statement started. Pages
+	// past it were created by the statement, and are removed on rollback
+	fileSize int64
+	pages    map[int][]byte
+}
+
+// Begin starts recording the pages changed by a statement
+func (s Storage) Begin() error {
+	stat, err := os.Stat(s.filePath)
+	if err != nil {
+		return err
+	}
+	*s.journal = pageJournal{active: true, fileSize: stat.Size(), pages: make(map[int][]byte)}
+	return nil
+}
+
+// Commit keeps the changes made since Begin
+func (s Storage) Commit() {
+	*s.journal = pageJournal{}
+}
+
+// Rollback restores the pages changed since Begin, and removes the pages
+// created since then
+func (s Storage) Rollback() error {
+	journal := *s.journal
+	*s.journal = pageJournal{}
+	if !journal.active {
+		return nil
+	}
+
+	file, err := os.OpenFile(s.filePath, os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	for pageIndex, contents := range journal.pages {
+		if _, err := file.WriteAt(contents, int64(pageIndex*s.pageSize)); err != nil {
+			return err
+		}
+	}
+	if err := file.Truncate(journal.fileSize); err != nil {
+		return err
+	}
+
+	// Cached pages and index keys may hold the changes that were undone
+	s.cache.Purge()
+	for name := range s.indexCache {
+		delete(s.indexCache, name)
+	}
+	return nil
+}
+
+// recordPage saves the contents of a page before it is first changed by the
+// current statement
+func (s Storage) recordPage(file *os.File, pageIndex int) error {
+	journal := s.journal
+	offset := int64(pageIndex * s.pageSize)
+	if !journal.active || offset >= journal.fileSize {
+		return nil
+	}
+	if _, found := journal.pages[pageIndex]; found {
+		return nil
+	}
+	contents := make([]byte, min(int64(s.pageSize), journal.fileSize-offset))
+	if _, err := file.ReadAt(contents, offset); err != nil {
+		return err
+	}
+	journal.pages[pageIndex] = contents
+	return nil
+}
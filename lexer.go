@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/hex"
 	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -18,20 +20,27 @@ const (
 	Eof TokenType = iota
 	Whitespace
 	String
+	HexString
 	Number
 	Keyword
 	Identifier
 	Operator
 	Wildcard
 	Comma
+	DoubleColon
 	LeftParenthesis
 	RightParenthesis
+	LeftBracket
+	RightBracket
+	Semicolon
 	UnknownTokenType
 )
 
 type Token struct {
 	Type  TokenType
 	Value interface{}
+	// Text is the token as written in the input
+	Text string
 }
 
 func NewLexer() Lexer {
@@ -50,6 +59,7 @@ func (l *Lexer) Scan(input string) []Token {
 		if token.Type == Whitespace {
 			continue
 		}
+		token.Text = l.currString()
 		tokens = append(tokens, token)
 	}
 	return tokens
@@ -66,6 +76,11 @@ func (l *Lexer) scanNext() Token {
 		return l.createToken(LeftParenthesis)
 	case l.matchChar(')'):
 		return l.createToken(RightParenthesis)
+	// BRACKETS
+	case l.matchChar('['):
+		return l.createToken(LeftBracket)
+	case l.matchChar(']'):
+		return l.createToken(RightBracket)
 	// WHITESPACE
 	case l.matchCharFunc(unicode.IsSpace):
 		for l.matchCharFunc(unicode.IsSpace) {
@@ -78,9 +93,25 @@ func (l *Lexer) scanNext() Token {
 			continue
 		}
 		return l.createToken(Number)
+	// HEX STRING
+	case l.matchString("x'") || l.matchString("X'"):
+		// Invalid digits are consumed up to the closing quote, so they are not
+		// read as the start of another token
+		for l.matchCharFunc(func(a rune) bool { return a != '\'' }) {
+			continue
+		}
+		if !l.matchChar('\'') {
+			return l.createToken(UnknownTokenType)
+		}
+		value, err := hex.DecodeString(l.input[l.currTokenStart+2 : l.cursor-1])
+		if err != nil {
+			return l.createToken(UnknownTokenType)
+		}
+		return Token{Type: HexString, Value: value}
 	// IDENTIFIER OR KEYWORD
 	case l.matchCharFunc(isLetterOrUnderscore):
-		for l.matchCharFunc(isAlphanumericOrUnderscore) {
+		// Qualified names, such as "schema.table", are a single identifier
+		for l.matchCharFunc(isAlphanumericOrUnderscore) || l.matchQualifier() {
 			continue
 		}
 		if stringIsKeyword(l.currString()) {
@@ -92,18 +123,26 @@ func (l *Lexer) scanNext() Token {
 		for l.matchCharFunc(func(a rune) bool { return a != '\'' }) {
 			continue
 		}
-		l.cursor++
+		if !l.matchChar('\'') {
+			return l.createToken(UnknownTokenType)
+		}
 		value := l.input[l.currTokenStart+1 : l.cursor-1]
 		return Token{Type: String, Value: value}
 	// COMMA
 	case l.matchChar(','):
 		return l.createToken(Comma)
+	// SEMICOLON
+	case l.matchChar(';'):
+		return l.createToken(Semicolon)
+	// DOUBLE COLON
+	case l.matchString("::"):
+		return l.createToken(DoubleColon)
 	// WILDCARD
 	case l.matchChar('*'):
 		return l.createToken(Wildcard)
 	// OPERATOR
 	case stringIsOperator(l.input[l.currTokenStart : l.cursor+1]):
-		for stringIsOperator(l.input[l.currTokenStart : l.cursor+1]) {
+		for l.cursor < len(l.input) && stringIsOperator(l.input[l.currTokenStart:l.cursor+1]) {
 			l.cursor++
 		}
 		return l.createToken(Operator)
@@ -124,6 +163,27 @@ func (l *Lexer) matchChar(value rune) bool {
 	return false
 }
 
+func (l *Lexer) matchString(value string) bool {
+	if !strings.HasPrefix(l.input[l.cursor:], value) {
+		return false
+	}
+	l.cursor += len(value)
+	return true
+}
+
+// matchQualifier matches the dot separating the parts of a qualified name
+func (l *Lexer) matchQualifier() bool {
+	if !strings.HasPrefix(l.input[l.cursor:], ".") || l.cursor+1 >= len(l.input) {
+		return false
+	}
+	char, _ := utf8.DecodeRuneInString(l.input[l.cursor+1:])
+	if !isLetterOrUnderscore(char) {
+		return false
+	}
+	l.cursor++
+	return true
+}
+
 func (l *Lexer) matchCharFunc(cb func(char rune) bool) bool {
 	if l.cursor >= len(l.input) {
 		return false
@@ -33,6 +33,38 @@ func stringIsKeyword(token string) bool {
 		"values",
 		"text",
 		"integer",
+		"blob",
+		"bytea",
+		"json",
+		"uuid",
+		"cast",
+		"as",
+		"array",
+		"any",
+		"all",
+		"drop",
+		"truncate",
+		"if",
+		"exists",
+		"alter",
+		"add",
+		"column",
+		"rename",
+		"to",
+		"default",
+		"primary",
+		"unique",
+		"update",
+		"set",
+		"not",
+		"null",
+		"check",
+		"foreign",
+		"references",
+		"on",
+		"delete",
+		"cascade",
+		"restrict",
 	}
 	return slices.Contains(keywords, token)
 }
@@ -47,7 +79,26 @@ func stringIsOperator(token string) bool {
 		"<=",
 		"+",
 		"-",
+		"/",
 		"%",
+		"->",
+		"->>",
 	}
 	return slices.Contains(operators, token)
 }
+
+// operatorPrecedence returns how tightly an operator binds its operands, with
+// higher values binding first
+func operatorPrecedence(operator string) int {
+	switch operator {
+	case "=", "<>", ">", ">=", "<", "<=":
+		return 1
+	case "+", "-":
+		return 2
+	case "*", "/", "%":
+		return 3
+	case "->", "->>":
+		return 4
+	}
+	return 0
+}
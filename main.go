@@ -13,7 +13,12 @@ func main() {
 }
 
 func repl() error {
-	backend := NewBackend()
+	backend, err := NewBackend()
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
 	inputReader := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Print("> ")
@@ -38,7 +43,5 @@ func execute(input string, backend *Backend) error {
 		return err
 	}
 
-	backend.Run(statement)
-
-	return nil
+	return backend.Run(statement)
 }
@@ -0,0 +1,105 @@
+package main
+
+// FreePageOwner is the owner of page directory entries for pages released by
+// dropped or truncated tables, which are reused before the file is grown
+const FreePageOwner = ""
+
+type PageDirectoryEntry struct {
+	Table     string
+	PageIndex int
+}
+
+func (s Storage) readPageDirectory() ([]PageDirectoryEntry, error) {
+	var entries []PageDirectoryEntry
+
+	pd, err := s.readPage(PageDirectoryIndex)
+	if err != nil {
+		return entries, err
+	}
+
+	pageLength := pd.ReadInt(IntSize)
+	for pd.Cursor() < pageLength {
+		table := pd.ReadString()
+		pageIndex := pd.ReadInt(SmallIntSize)
+		entries = append(entries, PageDirectoryEntry{Table: table, PageIndex: pageIndex})
+	}
+
+	return entries, nil
+}
+
+func (s Storage) writePageDirectory(entries []PageDirectoryEntry) error {
+	buf := NewByteStreamBuffer()
+	for _, entry := range entries {
+		buf.WriteString(entry.Table)
+		buf.WriteInt(entry.PageIndex, SmallIntSize)
+	}
+	return s.writePage(PageDirectoryIndex, buf.Bytes())
+}
+
+func (s Storage) appendPageDirectoryEntry(tableName string, pageIndex int) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteString(tableName)
+	buf.WriteInt(pageIndex, SmallIntSize)
+	return s.appendToPage(buf.Bytes(), PageDirectoryIndex)
+}
+
+// tablePages returns the pages containing data for a table, in the order they
+// were assigned to it
+func (s Storage) tablePages(tableName string) ([]int, error) {
+	var pages []int
+
+	entries, err := s.readPageDirectory()
+	if err != nil {
+		return pages, err
+	}
+	for _, entry := range entries {
+		if entry.Table == tableName {
+			pages = append(pages, entry.PageIndex)
+		}
+	}
+
+	return pages, nil
+}
+
+// releasePages moves all pages of a table into the free list
+func (s Storage) releasePages(tableName string) error {
+	entries, err := s.readPageDirectory()
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if entries[i].Table == tableName {
+			entries[i].Table = FreePageOwner
+		}
+	}
+	return s.writePageDirectory(entries)
+}
+
+func (s Storage) renamePages(tableName string, newName string) error {
+	entries, err := s.readPageDirectory()
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if entries[i].Table == tableName {
+			entries[i].Table = newName
+		}
+	}
+	return s.writePageDirectory(entries)
+}
+
+// takeFreePage removes the first page from the free list, returning false
+// when there are no free pages
+func (s Storage) takeFreePage() (int, bool, error) {
+	entries, err := s.readPageDirectory()
+	if err != nil {
+		return -1, false, err
+	}
+	for i, entry := range entries {
+		if entry.Table == FreePageOwner {
+			entries = append(entries[:i], entries[i+1:]...)
+			return entry.PageIndex, true, s.writePageDirectory(entries)
+		}
+	}
+	return -1, false, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDropAndTruncate(t *testing.T) {
+	setup := []string{
+		"create table t (id integer)",
+		"insert into t (id) values (1)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "truncate removes all rows",
+			statement: "truncate table t",
+			query:     "select id from t",
+		},
+		{
+			name:      "dropping unknown tables",
+			statement: "drop table u",
+			wantErr:   "definition for table u not found",
+		},
+		{
+			name:      "dropping unknown tables if they exist",
+			statement: "drop table if exists u",
+			query:     "select id from t",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "truncating unknown tables",
+			statement: "truncate u",
+			wantErr:   "definition for table u not found",
+		},
+		{
+			name:      "duplicate tables",
+			statement: "create table t (id integer)",
+			wantErr:   "relation t already exists",
+		},
+	})
+}
+
+func TestDroppedTablesCanBeCreatedAgain(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create table t (id integer)",
+		"insert into t (id) values (1)",
+		"drop table t",
+		"create table t (name text)",
+		"insert into t (name) values ('a')",
+	)
+	if got, want := query(t, backend, "select name from t"), [][]string{{"a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+	if err := exec(backend, "select id from u"); err == nil {
+		t.Error("expected selecting from a dropped table to fail")
+	}
+}
+
+func TestReleasedPagesAreReused(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend, "create table t (id integer, name text)")
+	fill := func(table string) {
+		for i := 0; i < 2000; i++ {
+			mustExec(t, backend, fmt.Sprintf("insert into %s (id, name) values (%d, 'some name')", table, i))
+		}
+	}
+	fill("t")
+	pages, _ := backend.storage.tablePages("t")
+	if len(pages) < 2 {
+		t.Fatalf("expected rows to take several pages, got %d", len(pages))
+	}
+
+	mustExec(t, backend, "truncate t", "create table u (id integer, name text)")
+	fill("u")
+	reused, _ := backend.storage.tablePages("u")
+	if !reflect.DeepEqual(reused, pages) {
+		t.Errorf("got pages %v, want the released pages %v", reused, pages)
+	}
+	if got := query(t, backend, "select id from t"); len(got) != 0 {
+		t.Errorf("got rows %v in truncated table", got)
+	}
+	if got, want := query(t, backend, "select count() from u"), [][]string{{"2000"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+}
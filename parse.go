@@ -4,115 +4,1225 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
-type Parser struct {
-	tokens []Token
-	cursor int
+type Parser struct {
+	tokens []Token
+	cursor int
+	// err holds the first error found inside an expression, as expressions
+	// are parsed without returning errors
+	err error
+}
+
+func NewParser() Parser {
+	return Parser{}
+}
+
+func (p *Parser) Parse(tokens []Token) (Statement, error) {
+	p.tokens = tokens
+	p.cursor = 0
+	p.err = nil
+
+	statement, err := p.parseStatement()
+	// Errors found inside an expression are more precise than the ones of the
+	// statement holding it
+	if p.err != nil {
+		return Statement{}, p.err
+	}
+	if err != nil {
+		return Statement{}, err
+	}
+	// Statements may end with a semicolon, and anything left after them is
+	// not valid
+	p.matchToken(Semicolon)
+	if p.cursor < len(p.tokens) {
+		return Statement{}, fmt.Errorf("syntax error at or near '%s'", p.tokens[p.cursor].Text)
+	}
+	return statement, nil
+}
+
+func (p *Parser) parseStatement() (Statement, error) {
+	var emptyStatement Statement
+
+	// Look for create table statement
+	createTableStatement, err := p.parseCreateTable()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if createTableStatement != (CreateTableStatement{}) {
+		return Statement{
+			CreateTable: createTableStatement,
+			Kind:        CreateTableKind,
+		}, nil
+	}
+
+	// Look for create type statement
+	createTypeStatement, err := p.parseCreateType()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if createTypeStatement != (CreateTypeStatement{}) {
+		return Statement{
+			CreateType: createTypeStatement,
+			Kind:       CreateTypeKind,
+		}, nil
+	}
+
+	// Look for create sequence statement
+	createSequenceStatement, err := p.parseCreateSequence()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if createSequenceStatement != (CreateSequenceStatement{}) {
+		return Statement{
+			CreateSequence: createSequenceStatement,
+			Kind:           CreateSequenceKind,
+		}, nil
+	}
+
+	// Look for drop sequence statement
+	dropSequenceStatement, err := p.parseDropSequence()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if dropSequenceStatement != (DropSequenceStatement{}) {
+		return Statement{
+			DropSequence: dropSequenceStatement,
+			Kind:         DropSequenceKind,
+		}, nil
+	}
+
+	// Look for create view statement
+	createViewStatement, err := p.parseCreateView()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if createViewStatement != (CreateViewStatement{}) {
+		return Statement{
+			CreateView: createViewStatement,
+			Kind:       CreateViewKind,
+		}, nil
+	}
+
+	// Look for drop view statement
+	dropViewStatement, err := p.parseDropView()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if dropViewStatement != (DropViewStatement{}) {
+		return Statement{
+			DropView: dropViewStatement,
+			Kind:     DropViewKind,
+		}, nil
+	}
+
+	// Look for refresh materialized view statement
+	refreshStatement, err := p.parseRefreshMaterializedView()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if refreshStatement != (RefreshMaterializedViewStatement{}) {
+		return Statement{
+			RefreshMaterializedView: refreshStatement,
+			Kind:                    RefreshMaterializedViewKind,
+		}, nil
+	}
+
+	// Look for create schema statement
+	createSchemaStatement, err := p.parseCreateSchema()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if createSchemaStatement != (CreateSchemaStatement{}) {
+		return Statement{
+			CreateSchema: createSchemaStatement,
+			Kind:         CreateSchemaKind,
+		}, nil
+	}
+
+	// Look for drop schema statement
+	dropSchemaStatement, err := p.parseDropSchema()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if dropSchemaStatement != (DropSchemaStatement{}) {
+		return Statement{
+			DropSchema: dropSchemaStatement,
+			Kind:       DropSchemaKind,
+		}, nil
+	}
+
+	// Look for set statement
+	setStatement, err := p.parseSet()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if setStatement != (SetStatement{}) {
+		return Statement{
+			Set:  setStatement,
+			Kind: SetKind,
+		}, nil
+	}
+
+	// Look for show statement
+	showStatement, err := p.parseShow()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if showStatement != (ShowStatement{}) {
+		return Statement{
+			Show: showStatement,
+			Kind: ShowKind,
+		}, nil
+	}
+
+	// Look for create trigger statement
+	createTriggerStatement, err := p.parseCreateTrigger()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if createTriggerStatement != (CreateTriggerStatement{}) {
+		return Statement{
+			CreateTrigger: createTriggerStatement,
+			Kind:          CreateTriggerKind,
+		}, nil
+	}
+
+	// Look for drop trigger statement
+	dropTriggerStatement, err := p.parseDropTrigger()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if dropTriggerStatement != (DropTriggerStatement{}) {
+		return Statement{
+			DropTrigger: dropTriggerStatement,
+			Kind:        DropTriggerKind,
+		}, nil
+	}
+
+	// Look for drop table statement
+	dropTableStatement, err := p.parseDropTable()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if dropTableStatement != (DropTableStatement{}) {
+		return Statement{
+			DropTable: dropTableStatement,
+			Kind:      DropTableKind,
+		}, nil
+	}
+
+	// Look for truncate table statement
+	truncateStatement, err := p.parseTruncateTable()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if truncateStatement != (TruncateTableStatement{}) {
+		return Statement{
+			Truncate: truncateStatement,
+			Kind:     TruncateTableKind,
+		}, nil
+	}
+
+	// Look for alter table statement
+	alterTableStatement, err := p.parseAlterTable()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if alterTableStatement != (AlterTableStatement{}) {
+		return Statement{
+			AlterTable: alterTableStatement,
+			Kind:       AlterTableKind,
+		}, nil
+	}
+
+	// Look for update statement
+	updateStatement, err := p.parseUpdate()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if updateStatement != (UpdateStatement{}) {
+		return Statement{
+			Update: updateStatement,
+			Kind:   UpdateKind,
+		}, nil
+	}
+
+	// Look for delete statement
+	deleteStatement, err := p.parseDelete()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if deleteStatement != (DeleteStatement{}) {
+		return Statement{
+			Delete: deleteStatement,
+			Kind:   DeleteKind,
+		}, nil
+	}
+
+	// Look for insert statement
+	insertStatement, err := p.parseInsert()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if insertStatement != (InsertStatement{}) {
+		return Statement{
+			Insert: insertStatement,
+			Kind:   InsertKind,
+		}, nil
+	}
+
+	// Look for select statement
+	selectStatement, err := p.parseSelect()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if selectStatement != (SelectStatement{}) {
+		return Statement{
+			Select: selectStatement,
+			Kind:   SelectKind,
+		}, nil
+	}
+
+	return emptyStatement, errors.New("unable to identify operation type")
+}
+
+func (p *Parser) parseCreateTable() (CreateTableStatement, error) {
+	var emptyStatement CreateTableStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("create") {
+		return emptyStatement, nil
+	}
+	temporary := p.matchWord("temporary") || p.matchWord("temp")
+	if !p.matchKeyword("table") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'create table'")
+	}
+
+	columns, constraints, err := p.parseCreateTableColumns()
+	if err != nil {
+		return emptyStatement, err
+	}
+
+	return CreateTableStatement{
+		Name:        table.Value.(string),
+		Columns:     &columns,
+		Constraints: &constraints,
+		Temporary:   temporary,
+	}, nil
+}
+
+func (p *Parser) parseCreateTableColumns() ([]ColumnDefinition, []TableConstraint, error) {
+	var columns []ColumnDefinition
+	var constraints []TableConstraint
+
+	if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
+		return columns, constraints, errors.New("expected column definitions after 'create table'")
+	}
+
+	for {
+		if p.matchToken(RightParenthesis) != (Token{}) {
+			break
+		}
+
+		// Table constraints, such as "primary key (a, b)"
+		if kind, ok, err := p.parseConstraintKind(); err != nil {
+			return columns, constraints, err
+		} else if ok {
+			constraintColumns, err := p.parseColumnList()
+			if err != nil {
+				return columns, constraints, err
+			}
+			constraint := TableConstraint{Kind: kind, Columns: constraintColumns}
+			if kind == ForeignKeyConstraint {
+				if !p.matchKeyword("references") {
+					return columns, constraints, errors.New("expected 'references' after foreign key columns")
+				}
+				if err := p.parseReferences(&constraint); err != nil {
+					return columns, constraints, err
+				}
+			}
+			constraints = append(constraints, constraint)
+			p.matchToken(Comma)
+			continue
+		}
+
+		column, err := p.parseColumnDefinition()
+		if err != nil {
+			return columns, constraints, err
+		}
+		columnConstraints, err := p.parseColumnClauses(&column)
+		if err != nil {
+			return columns, constraints, err
+		}
+		columns = append(columns, column)
+		constraints = append(constraints, columnConstraints...)
+
+		p.matchToken(Comma)
+	}
+	return columns, constraints, nil
+}
+
+// parseColumnClauses parses the clauses following a column type, such as
+// "not null" or "default 0". Keys are returned as table constraints
+func (p *Parser) parseColumnClauses(column *ColumnDefinition) ([]TableConstraint, error) {
+	var constraints []TableConstraint
+
+	for {
+		switch {
+		case p.matchKeyword("not null"):
+			column.NotNull = true
+		case p.matchKeyword("null"):
+			column.NotNull = false
+		case p.matchKeyword("default"):
+			column.Default = p.parseItem()
+			if column.Default == (Expression{}) {
+				return constraints, errors.New("expected valid expression after 'default'")
+			}
+		case p.matchKeyword("check"):
+			if p.matchToken(LeftParenthesis) == (Token{}) {
+				return constraints, errors.New("expected '(' after 'check'")
+			}
+			column.Check = p.parseItem()
+			if column.Check == (Expression{}) {
+				return constraints, errors.New("expected valid expression after 'check'")
+			}
+			if p.matchToken(RightParenthesis) == (Token{}) {
+				return constraints, errors.New("expected ')' after check expression")
+			}
+		case p.matchWord("generated"):
+			if column.Identity != NoIdentity || column.Generated != (Expression{}) {
+				return constraints, fmt.Errorf("multiple generation clauses specified for column %s", column.Name)
+			}
+			var identity IdentityKind
+			switch {
+			case p.matchWord("always"):
+				identity = IdentityAlways
+			case p.matchKeyword("by default"):
+				identity = IdentityByDefault
+			default:
+				return constraints, errors.New("expected 'always' or 'by default' after 'generated'")
+			}
+			if !p.matchKeyword("as") {
+				return constraints, errors.New("expected 'as' after 'generated'")
+			}
+			// Stored generated columns, such as "generated always as (a * 2) stored"
+			if identity == IdentityAlways && p.matchToken(LeftParenthesis) != (Token{}) {
+				column.Generated = p.parseItem()
+				if column.Generated == (Expression{}) {
+					return constraints, errors.New("expected valid expression after 'generated always as ('")
+				}
+				if p.matchToken(RightParenthesis) == (Token{}) {
+					return constraints, errors.New("expected ')' after generation expression")
+				}
+				if !p.matchWord("stored") {
+					return constraints, errors.New("expected 'stored' after generation expression")
+				}
+				continue
+			}
+			if !p.matchWord("identity") {
+				return constraints, errors.New("expected 'identity' or a generation expression after 'generated always as'")
+			}
+			column.Identity = identity
+		case p.matchKeyword("references"):
+			constraint := TableConstraint{Kind: ForeignKeyConstraint, Columns: []string{column.Name}}
+			if err := p.parseReferences(&constraint); err != nil {
+				return constraints, err
+			}
+			constraints = append(constraints, constraint)
+		default:
+			kind, ok, err := p.parseConstraintKind()
+			if err != nil {
+				return constraints, err
+			}
+			if !ok {
+				return constraints, nil
+			}
+			if kind == ForeignKeyConstraint {
+				return constraints, errors.New("expected 'references' instead of 'foreign key' in column definition")
+			}
+			constraints = append(constraints, TableConstraint{Kind: kind, Columns: []string{column.Name}})
+		}
+	}
+}
+
+func (p *Parser) parseConstraintKind() (ConstraintKind, bool, error) {
+	switch {
+	case p.matchKeyword("primary"):
+		if !p.matchWord("key") {
+			return PrimaryKeyConstraint, false, errors.New("expected 'key' after 'primary'")
+		}
+		return PrimaryKeyConstraint, true, nil
+	case p.matchKeyword("unique"):
+		return UniqueConstraint, true, nil
+	case p.matchKeyword("foreign"):
+		if !p.matchWord("key") {
+			return ForeignKeyConstraint, false, errors.New("expected 'key' after 'foreign'")
+		}
+		return ForeignKeyConstraint, true, nil
+	}
+	return PrimaryKeyConstraint, false, nil
+}
+
+// parseReferences parses the table referenced by a foreign key, followed by
+// optional referenced columns and action
+func (p *Parser) parseReferences(constraint *TableConstraint) error {
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return errors.New("expected identifier after 'references'")
+	}
+	constraint.References = table.Value.(string)
+
+	if p.cursor < len(p.tokens) && p.tokens[p.cursor].Type == LeftParenthesis {
+		columns, err := p.parseColumnList()
+		if err != nil {
+			return err
+		}
+		constraint.ReferencedColumns = columns
+	}
+
+	if p.matchKeyword("on delete") {
+		switch {
+		case p.matchKeyword("cascade"):
+			constraint.OnDelete = CascadeAction
+		case p.matchKeyword("restrict"):
+			constraint.OnDelete = RestrictAction
+		case p.matchKeyword("set null"):
+			constraint.OnDelete = SetNullAction
+		case p.matchWord("no") && p.matchWord("action"):
+			constraint.OnDelete = NoAction
+		default:
+			return errors.New("expected 'cascade', 'set null', 'restrict' or 'no action' after 'on delete'")
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseColumnList() ([]string, error) {
+	var columns []string
+
+	if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
+		return columns, errors.New("expected columns list")
+	}
+
+	for {
+		if p.matchToken(RightParenthesis) != (Token{}) {
+			break
+		}
+
+		column := p.matchToken(Identifier)
+		if column == (Token{}) {
+			return columns, errors.New("expected column name")
+		}
+		columns = append(columns, column.Value.(string))
+
+		p.matchToken(Comma)
+	}
+
+	if len(columns) == 0 {
+		return columns, errors.New("expected at least one column in columns list")
+	}
+	return columns, nil
+}
+
+func (p *Parser) parseColumnDefinition() (ColumnDefinition, error) {
+	var column ColumnDefinition
+
+	columnName := p.matchToken(Identifier)
+	if columnName == (Token{}) {
+		return column, errors.New("expected column name")
+	}
+
+	columnType := p.parseTypeName()
+	if columnType == "" {
+		return column, fmt.Errorf("expected column type after '%s'", columnName.Value)
+	}
+
+	// Serial columns are integers generated by a sequence
+	if columnType == "serial" {
+		return ColumnDefinition{Name: columnName.Value.(string), Type: "integer", Identity: IdentityByDefault}, nil
+	}
+	return ColumnDefinition{Name: columnName.Value.(string), Type: columnType}, nil
+}
+
+func (p *Parser) parseCreateType() (CreateTypeStatement, error) {
+	var emptyStatement CreateTypeStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("create") || !p.matchWord("type") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'create type'")
+	}
+
+	if !p.matchKeyword("as") || !p.matchWord("enum") {
+		return emptyStatement, errors.New("expected 'as enum' after type name")
+	}
+
+	labels, err := p.parseEnumLabels()
+	if err != nil {
+		return emptyStatement, err
+	}
+
+	return CreateTypeStatement{
+		Name:   name.Value.(string),
+		Labels: &labels,
+	}, nil
+}
+
+func (p *Parser) parseEnumLabels() ([]string, error) {
+	var labels []string
+
+	if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
+		return labels, errors.New("expected labels list after 'as enum'")
+	}
+
+	for {
+		if p.matchToken(RightParenthesis) != (Token{}) {
+			break
+		}
+
+		label := p.matchToken(String)
+		if label == (Token{}) {
+			return labels, errors.New("expected string label")
+		}
+		if slices.Contains(labels, label.Value.(string)) {
+			return labels, fmt.Errorf("duplicate enum label '%s'", label.Value)
+		}
+
+		labels = append(labels, label.Value.(string))
+
+		p.matchToken(Comma)
+	}
+
+	if len(labels) == 0 {
+		return labels, errors.New("expected at least one enum label")
+	}
+	return labels, nil
+}
+
+func (p *Parser) parseCreateSequence() (CreateSequenceStatement, error) {
+	var emptyStatement CreateSequenceStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("create") || !p.matchWord("sequence") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'create sequence'")
+	}
+	statement := CreateSequenceStatement{Name: name.Value.(string), Start: 1, Increment: 1}
+
+	for {
+		switch {
+		case p.matchWord("start"):
+			p.matchWord("with")
+			start := p.matchToken(Number)
+			if start == (Token{}) {
+				return emptyStatement, errors.New("expected number after 'start'")
+			}
+			statement.Start = start.Value.(int)
+		case p.matchWord("increment"):
+			p.matchKeyword("by")
+			increment := p.matchToken(Number)
+			if increment == (Token{}) {
+				return emptyStatement, errors.New("expected number after 'increment'")
+			}
+			statement.Increment = increment.Value.(int)
+		default:
+			return statement, nil
+		}
+	}
+}
+
+func (p *Parser) parseDropSequence() (DropSequenceStatement, error) {
+	var emptyStatement DropSequenceStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("drop") || !p.matchWord("sequence") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	ifExists := p.matchKeyword("if exists")
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'drop sequence'")
+	}
+
+	return DropSequenceStatement{
+		Name:     name.Value.(string),
+		IfExists: ifExists,
+	}, nil
+}
+
+func (p *Parser) parseCreateView() (CreateViewStatement, error) {
+	var emptyStatement CreateViewStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("create") {
+		return emptyStatement, nil
+	}
+	materialized := p.matchWord("materialized")
+	if !p.matchWord("view") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'create view'")
+	}
+	statement := CreateViewStatement{Name: name.Value.(string), Materialized: materialized}
+
+	if p.cursor < len(p.tokens) && p.tokens[p.cursor].Type == LeftParenthesis {
+		columns, err := p.parseColumnList()
+		if err != nil {
+			return emptyStatement, err
+		}
+		statement.Columns = &columns
+	}
+
+	if !p.matchKeyword("as") {
+		return emptyStatement, errors.New("expected 'as' after view name")
+	}
+
+	// The query is stored as text, and parsed again whenever the view is used
+	start := p.cursor
+	query, err := p.parseSelect()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if query == (SelectStatement{}) {
+		return emptyStatement, errors.New("expected select statement after 'as'")
+	}
+	var text []string
+	for _, token := range p.tokens[start:p.cursor] {
+		text = append(text, token.Text)
+	}
+	statement.Query = strings.Join(text, " ")
+
+	return statement, nil
+}
+
+func (p *Parser) parseDropView() (DropViewStatement, error) {
+	var emptyStatement DropViewStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("drop") {
+		return emptyStatement, nil
+	}
+	materialized := p.matchWord("materialized")
+	if !p.matchWord("view") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	ifExists := p.matchKeyword("if exists")
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'drop view'")
+	}
+
+	return DropViewStatement{
+		Name:         name.Value.(string),
+		IfExists:     ifExists,
+		Materialized: materialized,
+	}, nil
+}
+
+func (p *Parser) parseRefreshMaterializedView() (RefreshMaterializedViewStatement, error) {
+	var emptyStatement RefreshMaterializedViewStatement
+
+	cursor := p.cursor
+	if !p.matchWord("refresh") || !p.matchWord("materialized") || !p.matchWord("view") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'refresh materialized view'")
+	}
+
+	return RefreshMaterializedViewStatement{Name: name.Value.(string)}, nil
+}
+
+func (p *Parser) parseCreateSchema() (CreateSchemaStatement, error) {
+	var emptyStatement CreateSchemaStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("create") || !p.matchWord("schema") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	ifNotExists := p.matchKeyword("if not exists")
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'create schema'")
+	}
+	if strings.Contains(name.Value.(string), ".") {
+		return emptyStatement, fmt.Errorf("invalid schema name %s", name.Value)
+	}
+
+	return CreateSchemaStatement{
+		Name:        name.Value.(string),
+		IfNotExists: ifNotExists,
+	}, nil
+}
+
+func (p *Parser) parseDropSchema() (DropSchemaStatement, error) {
+	var emptyStatement DropSchemaStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("drop") || !p.matchWord("schema") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	ifExists := p.matchKeyword("if exists")
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'drop schema'")
+	}
+
+	return DropSchemaStatement{
+		Name:     name.Value.(string),
+		IfExists: ifExists,
+	}, nil
+}
+
+func (p *Parser) parseSet() (SetStatement, error) {
+	var emptyStatement SetStatement
+
+	if !p.matchKeyword("set") {
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected setting name after 'set'")
+	}
+	if !p.matchKeyword("to") && p.matchToken(Operator).Value != "=" {
+		return emptyStatement, fmt.Errorf("expected 'to' or '=' after '%s'", name.Value)
+	}
+
+	var values []string
+	for {
+		value := p.matchToken(Identifier, String)
+		if value == (Token{}) {
+			return emptyStatement, fmt.Errorf("expected value for setting %s", name.Value)
+		}
+		values = append(values, value.Value.(string))
+		if p.matchToken(Comma) == (Token{}) {
+			break
+		}
+	}
+
+	return SetStatement{Name: name.Value.(string), Values: &values}, nil
+}
+
+func (p *Parser) parseShow() (ShowStatement, error) {
+	var emptyStatement ShowStatement
+
+	if !p.matchWord("show") {
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected setting name after 'show'")
+	}
+
+	return ShowStatement{Name: name.Value.(string)}, nil
+}
+
+func (p *Parser) parseCreateTrigger() (CreateTriggerStatement, error) {
+	var emptyStatement CreateTriggerStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("create") {
+		return emptyStatement, nil
+	}
+	if !p.matchWord("trigger") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'create trigger'")
+	}
+	statement := CreateTriggerStatement{Name: name.Value.(string)}
+
+	switch {
+	case p.matchWord("before"):
+		statement.Timing = BeforeTrigger
+	case p.matchWord("after"):
+		statement.Timing = AfterTrigger
+	default:
+		return emptyStatement, errors.New("expected 'before' or 'after' after trigger name")
+	}
+
+	switch {
+	case p.matchKeyword("insert"):
+		statement.Event = InsertTrigger
+	case p.matchKeyword("update"):
+		statement.Event = UpdateTrigger
+	case p.matchKeyword("delete"):
+		statement.Event = DeleteTrigger
+	default:
+		return emptyStatement, errors.New("expected 'insert', 'update' or 'delete' after trigger timing")
+	}
+
+	if !p.matchKeyword("on") {
+		return emptyStatement, errors.New("expected 'on' after trigger event")
+	}
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'on'")
+	}
+	statement.Table = table.Value.(string)
+
+	if !p.matchWord("for") || !p.matchWord("each") || !p.matchWord("row") {
+		return emptyStatement, errors.New("expected 'for each row' after table name")
+	}
+
+	body, err := p.parseTriggerBody()
+	if err != nil {
+		return emptyStatement, err
+	}
+	statement.Body = &body
+
+	return statement, nil
+}
+
+// parseTriggerBody parses the statements between 'begin' and 'end', each of
+// them followed by a semicolon, and returns their text
+func (p *Parser) parseTriggerBody() ([]string, error) {
+	var body []string
+
+	if !p.matchWord("begin") {
+		return body, errors.New("expected 'begin' before trigger body")
+	}
+
+	for !p.matchWord("end") {
+		start := p.cursor
+		found, err := p.parseTriggerStatement()
+		if err != nil {
+			return body, err
+		}
+		if !found {
+			return body, errors.New("expected insert, update or delete statement in trigger body")
+		}
+
+		var text []string
+		for _, token := range p.tokens[start:p.cursor] {
+			text = append(text, token.Text)
+		}
+		body = append(body, strings.Join(text, " "))
+
+		if p.matchToken(Semicolon) == (Token{}) {
+			return body, errors.New("expected ';' after statement in trigger body")
+		}
+	}
+
+	if len(body) == 0 {
+		return body, errors.New("expected at least one statement in trigger body")
+	}
+
+	return body, nil
+}
+
+// parseTriggerStatement parses a statement of a trigger body, which may only
+// change data
+func (p *Parser) parseTriggerStatement() (bool, error) {
+	insertStatement, err := p.parseInsert()
+	if err != nil || insertStatement != (InsertStatement{}) {
+		return err == nil, triggerStatementError(insertStatement.Returning, err)
+	}
+	updateStatement, err := p.parseUpdate()
+	if err != nil || updateStatement != (UpdateStatement{}) {
+		return err == nil, triggerStatementError(updateStatement.Returning, err)
+	}
+	deleteStatement, err := p.parseDelete()
+	if err != nil || deleteStatement != (DeleteStatement{}) {
+		return err == nil, triggerStatementError(deleteStatement.Returning, err)
+	}
+	return false, nil
+}
+
+// triggerStatementError rejects returning clauses in trigger bodies, as there
+// is nowhere to return their rows to
+func triggerStatementError(returning *[]Expression, err error) error {
+	if err == nil && returning != nil {
+		return errors.New("returning is not allowed in trigger bodies")
+	}
+	return err
+}
+
+func (p *Parser) parseDropTrigger() (DropTriggerStatement, error) {
+	var emptyStatement DropTriggerStatement
+
+	cursor := p.cursor
+	if !p.matchKeyword("drop") {
+		return emptyStatement, nil
+	}
+	if !p.matchWord("trigger") {
+		p.cursor = cursor
+		return emptyStatement, nil
+	}
+
+	ifExists := p.matchKeyword("if exists")
+
+	name := p.matchToken(Identifier)
+	if name == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'drop trigger'")
+	}
+
+	if !p.matchKeyword("on") {
+		return emptyStatement, errors.New("expected 'on' after trigger name")
+	}
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'on'")
+	}
+
+	return DropTriggerStatement{
+		Name:     name.Value.(string),
+		Table:    table.Value.(string),
+		IfExists: ifExists,
+	}, nil
+}
+
+func (p *Parser) parseDropTable() (DropTableStatement, error) {
+	var emptyStatement DropTableStatement
+
+	if !p.matchKeyword("drop table") {
+		return emptyStatement, nil
+	}
+
+	ifExists := p.matchKeyword("if exists")
+
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'drop table'")
+	}
+
+	return DropTableStatement{
+		Name:     table.Value.(string),
+		IfExists: ifExists,
+	}, nil
+}
+
+func (p *Parser) parseTruncateTable() (TruncateTableStatement, error) {
+	var emptyStatement TruncateTableStatement
+
+	if !p.matchKeyword("truncate") {
+		return emptyStatement, nil
+	}
+	p.matchKeyword("table")
+
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'truncate table'")
+	}
+
+	return TruncateTableStatement{Name: table.Value.(string)}, nil
+}
+
+func (p *Parser) parseAlterTable() (AlterTableStatement, error) {
+	var emptyStatement AlterTableStatement
+
+	if !p.matchKeyword("alter table") {
+		return emptyStatement, nil
+	}
+
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'alter table'")
+	}
+	statement := AlterTableStatement{Table: table.Value.(string)}
+
+	switch {
+	case p.matchKeyword("add"):
+		p.matchKeyword("column")
+		column, err := p.parseColumnDefinition()
+		if err != nil {
+			return emptyStatement, err
+		}
+		constraints, err := p.parseColumnClauses(&column)
+		if err != nil {
+			return emptyStatement, err
+		}
+		if len(constraints) > 0 {
+			return emptyStatement, errors.New("key constraints cannot be added along with a column")
+		}
+		statement.Action = AddColumnAction
+		statement.Column = column
+	case p.matchKeyword("drop"):
+		p.matchKeyword("column")
+		column := p.matchToken(Identifier)
+		if column == (Token{}) {
+			return emptyStatement, errors.New("expected column name after 'drop column'")
+		}
+		statement.Action = DropColumnAction
+		statement.Column = ColumnDefinition{Name: column.Value.(string)}
+	case p.matchKeyword("rename to"):
+		newName := p.matchToken(Identifier)
+		if newName == (Token{}) {
+			return emptyStatement, errors.New("expected identifier after 'rename to'")
+		}
+		statement.Action = RenameTableAction
+		statement.NewName = newName.Value.(string)
+	case p.matchKeyword("rename"):
+		p.matchKeyword("column")
+		column := p.matchToken(Identifier)
+		if column == (Token{}) {
+			return emptyStatement, errors.New("expected column name after 'rename column'")
+		}
+		if !p.matchKeyword("to") {
+			return emptyStatement, errors.New("expected 'to' after column name")
+		}
+		newName := p.matchToken(Identifier)
+		if newName == (Token{}) {
+			return emptyStatement, errors.New("expected identifier after 'to'")
+		}
+		statement.Action = RenameColumnAction
+		statement.Column = ColumnDefinition{Name: column.Value.(string)}
+		statement.NewName = newName.Value.(string)
+	default:
+		return emptyStatement, errors.New("expected 'add', 'drop' or 'rename' after table name")
+	}
+
+	return statement, nil
 }
 
-func NewParser() Parser {
-	return Parser{}
-}
+func (p *Parser) parseUpdate() (UpdateStatement, error) {
+	var emptyStatement UpdateStatement
 
-func (p *Parser) Parse(tokens []Token) (Statement, error) {
-	var emptyStatement Statement
+	if !p.matchKeyword("update") {
+		return emptyStatement, nil
+	}
 
-	p.tokens = tokens
-	p.cursor = 0
+	table := p.matchToken(Identifier)
+	if table == (Token{}) {
+		return emptyStatement, errors.New("expected identifier after 'update'")
+	}
 
-	// Look for create table statement
-	createTableStatement, err := p.parseCreateTable()
+	if !p.matchKeyword("set") {
+		return emptyStatement, errors.New("expected 'set' after table name")
+	}
+
+	assignments, err := p.parseAssignments()
 	if err != nil {
 		return emptyStatement, err
 	}
-	if createTableStatement != (CreateTableStatement{}) {
-		return Statement{
-			CreateTable: createTableStatement,
-			Kind:        CreateTableKind,
-		}, nil
-	}
 
-	// Look for insert statement
-	insertStatement, err := p.parseInsert()
+	where, err := p.parseExpression("where")
 	if err != nil {
 		return emptyStatement, err
 	}
-	if insertStatement != (InsertStatement{}) {
-		return Statement{
-			Insert: insertStatement,
-			Kind:   InsertKind,
-		}, nil
-	}
 
-	// Look for select statement
-	selectStatement, err := p.parseSelect()
+	returning, err := p.parseReturning()
 	if err != nil {
 		return emptyStatement, err
 	}
-	if selectStatement != (SelectStatement{}) {
-		return Statement{
-			Select: selectStatement,
-			Kind:   SelectKind,
-		}, nil
-	}
 
-	return emptyStatement, errors.New("unable to identify operation type")
+	return UpdateStatement{
+		Table:     table.Value.(string),
+		Set:       &assignments,
+		Where:     where,
+		Returning: returning,
+	}, nil
 }
 
-func (p *Parser) parseCreateTable() (CreateTableStatement, error) {
-	var emptyStatement CreateTableStatement
+func (p *Parser) parseAssignments() ([]UpdateAssignment, error) {
+	var assignments []UpdateAssignment
+	for {
+		column := p.matchToken(Identifier)
+		if column == (Token{}) {
+			return assignments, errors.New("expected column name")
+		}
+		if operator := p.matchToken(Operator); operator.Value != "=" {
+			return assignments, fmt.Errorf("expected '=' after '%s'", column.Value)
+		}
+		value := p.parseItem()
+		if value == (Expression{}) {
+			return assignments, fmt.Errorf("expected valid expression for column '%s'", column.Value)
+		}
+		assignments = append(assignments, UpdateAssignment{Column: column.Value.(string), Value: value})
+
+		if p.matchToken(Comma) == (Token{}) {
+			break
+		}
+	}
+	return assignments, nil
+}
+
+func (p *Parser) parseDelete() (DeleteStatement, error) {
+	var emptyStatement DeleteStatement
 
-	if !p.matchKeyword("create table") {
+	if !p.matchKeyword("delete from") {
 		return emptyStatement, nil
 	}
 
 	table := p.matchToken(Identifier)
 	if table == (Token{}) {
-		return emptyStatement, errors.New("expected identifier after 'create table'")
+		return emptyStatement, errors.New("expected identifier after 'delete from'")
+	}
+
+	where, err := p.parseExpression("where")
+	if err != nil {
+		return emptyStatement, err
 	}
 
-	columns, err := p.parseCreateTableColumns()
+	returning, err := p.parseReturning()
 	if err != nil {
 		return emptyStatement, err
 	}
 
-	return CreateTableStatement{
-		Name:    table.Value.(string),
-		Columns: &columns,
+	return DeleteStatement{
+		Table:     table.Value.(string),
+		Where:     where,
+		Returning: returning,
 	}, nil
 }
 
-func (p *Parser) parseCreateTableColumns() ([]ColumnDefinition, error) {
-	var columns []ColumnDefinition
-
-	if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
-		return columns, errors.New("expected column definitions after 'create table'")
+// parseReturning parses the items of a returning clause, which are nil when
+// there is no clause
+func (p *Parser) parseReturning() (*[]Expression, error) {
+	if !p.matchWord("returning") {
+		return nil, nil
 	}
-
-	for {
-		if p.matchToken(RightParenthesis) != (Token{}) {
-			break
-		}
-
-		columnName := p.matchToken(Identifier)
-		if columnName == (Token{}) {
-			return columns, errors.New("expected column name")
-		}
-
-		columnType := p.matchToken(Keyword)
-		if columnType == (Token{}) {
-			return columns, fmt.Errorf("expected column type after '%s'", columnName.Value)
-		}
-
-		columns = append(
-			columns,
-			ColumnDefinition{Name: columnName.Value.(string), Type: columnType.Value.(string)},
-		)
-
-		p.matchToken(Comma)
+	items, err := p.parseSelectItems()
+	if err != nil {
+		return nil, err
 	}
-	return columns, nil
+	if len(items) == 0 {
+		return nil, errors.New("expected items after 'returning'")
+	}
+	return &items, nil
 }
 
 func (p *Parser) parseInsert() (InsertStatement, error) {
@@ -126,29 +1236,100 @@ func (p *Parser) parseInsert() (InsertStatement, error) {
 	if table == (Token{}) {
 		return emptyStatement, errors.New("expected identifier after 'insert into'")
 	}
+	var alias string
+	if p.matchKeyword("as") {
+		token := p.matchToken(Identifier)
+		if token == (Token{}) {
+			return emptyStatement, errors.New("expected alias after 'as'")
+		}
+		alias = token.Value.(string)
+	}
 
 	columns, err := p.parseInsertColumns()
 	if err != nil {
 		return emptyStatement, err
 	}
 
-	values, err := p.parseInsertValues()
+	query, err := p.parseSelect()
 	if err != nil {
 		return emptyStatement, err
 	}
+	statement := InsertStatement{Table: table.Value.(string), Columns: columns}
+	if query != (SelectStatement{}) {
+		statement.Query = &query
+	} else {
+		values, err := p.parseInsertValues()
+		if err != nil {
+			return emptyStatement, err
+		}
+		statement.Values = &values
+	}
 
-	return InsertStatement{
-		Table:   table.Value.(string),
-		Columns: &columns,
-		Values:  &values,
-	}, nil
+	if statement.OnConflict, err = p.parseOnConflict(); err != nil {
+		return emptyStatement, err
+	}
+	if statement.OnConflict != nil {
+		statement.OnConflict.Alias = alias
+	}
+	if statement.Returning, err = p.parseReturning(); err != nil {
+		return emptyStatement, err
+	}
+
+	return statement, nil
+}
+
+func (p *Parser) parseOnConflict() (*OnConflict, error) {
+	cursor := p.cursor
+	if !p.matchKeyword("on") {
+		return nil, nil
+	}
+	if !p.matchWord("conflict") {
+		p.cursor = cursor
+		return nil, nil
+	}
+
+	onConflict := &OnConflict{}
+	if p.cursor < len(p.tokens) && p.tokens[p.cursor].Type == LeftParenthesis {
+		columns, err := p.parseColumnList()
+		if err != nil {
+			return nil, err
+		}
+		onConflict.Columns = &columns
+	}
+
+	if !p.matchWord("do") {
+		return nil, errors.New("expected 'do' after 'on conflict'")
+	}
+	if p.matchWord("nothing") {
+		return onConflict, nil
+	}
+	if !p.matchKeyword("update") || !p.matchKeyword("set") {
+		return nil, errors.New("expected 'nothing' or 'update set' after 'do'")
+	}
+	if onConflict.Columns == nil {
+		return nil, errors.New("on conflict do update requires conflict columns")
+	}
+
+	assignments, err := p.parseAssignments()
+	if err != nil {
+		return nil, err
+	}
+	onConflict.Set = &assignments
+
+	if onConflict.Where, err = p.parseExpression("where"); err != nil {
+		return nil, err
+	}
+
+	return onConflict, nil
 }
 
-func (p *Parser) parseInsertColumns() ([]Expression, error) {
+// parseInsertColumns parses the optional columns list of an insert, returning
+// nil when values are given for the columns of the table in order
+func (p *Parser) parseInsertColumns() (*[]Expression, error) {
 	var columns []Expression
 
 	if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
-		return columns, errors.New("expected columns list after 'insert into <table_name>'")
+		return nil, nil
 	}
 
 	for {
@@ -158,7 +1339,7 @@ func (p *Parser) parseInsertColumns() ([]Expression, error) {
 
 		column := p.matchToken(Identifier)
 		if column == (Token{}) {
-			return columns, errors.New("expected column name")
+			return nil, errors.New("expected column name")
 		}
 
 		columns = append(
@@ -169,38 +1350,47 @@ func (p *Parser) parseInsertColumns() ([]Expression, error) {
 		p.matchToken(Comma)
 	}
 
-	return columns, nil
+	return &columns, nil
 }
 
-func (p *Parser) parseInsertValues() ([]Expression, error) {
-	var values []Expression
+func (p *Parser) parseInsertValues() ([][]Expression, error) {
+	var rows [][]Expression
 	if !p.matchKeyword("values") {
-		return values, errors.New("expected 'values' after columns list")
-	}
-
-	if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
-		return values, errors.New("expected values list after 'values'")
+		return rows, errors.New("expected 'values' or 'select' after table name or columns list")
 	}
 
 	for {
-		if p.matchToken(RightParenthesis) != (Token{}) {
-			break
+		if lp := p.matchToken(LeftParenthesis); lp == (Token{}) {
+			return rows, errors.New("expected values list after 'values'")
 		}
 
-		value := p.matchToken(Number, String)
-		if value == (Token{}) {
-			return values, errors.New("expected literal")
-		}
+		var values []Expression
+		for {
+			// Identifiers are only valid as references to the new and old rows
+			// in the body of a trigger
+			value := p.parseItem()
+			if value == (Expression{}) {
+				return rows, errors.New("expected expression in values list")
+			}
 
-		values = append(
-			values,
-			Expression{Kind: LiteralExpressionKind, Literal: value.Value},
-		)
+			values = append(values, value)
 
-		p.matchToken(Comma)
+			if p.matchToken(RightParenthesis) != (Token{}) {
+				break
+			}
+			if p.matchToken(Comma) == (Token{}) {
+				return rows, errors.New("expected ',' or ')' after value")
+			}
+		}
+		rows = append(rows, values)
+
+		// Rows are separated by commas
+		if p.matchToken(Comma) == (Token{}) {
+			break
+		}
 	}
 
-	return values, nil
+	return rows, nil
 }
 
 func (p *Parser) parseSelect() (SelectStatement, error) {
@@ -211,10 +1401,16 @@ func (p *Parser) parseSelect() (SelectStatement, error) {
 	}
 
 	// Select ...
-	items := p.parseSelectItems()
+	items, err := p.parseSelectItems()
+	if err != nil {
+		return emptyStatement, err
+	}
+	if len(items) == 0 {
+		return emptyStatement, errors.New("expected valid expression after 'select'")
+	}
 
 	// From ...
-	table, err := p.parseSelectTable()
+	table, function, err := p.parseSelectTable()
 	if err != nil {
 		return emptyStatement, err
 	}
@@ -250,22 +1446,26 @@ func (p *Parser) parseSelect() (SelectStatement, error) {
 	}
 
 	return SelectStatement{
-		Table:   table,
-		Items:   &items,
-		Where:   where,
-		GroupBy: groupBy,
-		OrderBy: orderBy,
-		Limit:   limit,
-		Offset:  offset,
+		Table:    table,
+		Function: function,
+		Items:    &items,
+		Where:    where,
+		GroupBy:  groupBy,
+		OrderBy:  orderBy,
+		Limit:    limit,
+		Offset:   offset,
 	}, nil
 }
 
-func (p *Parser) parseSelectItems() []Expression {
+func (p *Parser) parseSelectItems() ([]Expression, error) {
 	var items []Expression
 
 	for {
 		item := p.parseItem()
 		if item == (Expression{}) {
+			if len(items) > 0 {
+				return items, errors.New("expected valid expression after ','")
+			}
 			break
 		}
 		items = append(items, item)
@@ -274,61 +1474,284 @@ func (p *Parser) parseSelectItems() []Expression {
 		}
 	}
 
-	return items
+	return items, nil
 }
 
 func (p *Parser) parseItem() Expression {
-	for {
-		var expression Expression
+	return p.parseBinaryItem(0)
+}
 
-		item := p.matchToken(Identifier, Wildcard, Number, String)
-		if item == (Token{}) {
-			return expression
-		}
+// parseBinaryItem parses operands joined by operators that bind at least as
+// tightly as minPrecedence, so binary expressions are left-associative
+func (p *Parser) parseBinaryItem(minPrecedence int) Expression {
+	expression := p.parseOperand()
+	if expression == (Expression{}) {
+		return expression
+	}
 
-		if item.Type == Identifier {
+	// The wildcard token doubles as the multiplication operator
+	for p.cursor < len(p.tokens) && (p.tokens[p.cursor].Type == Operator || p.tokens[p.cursor].Type == Wildcard) {
+		operator := p.tokens[p.cursor].Value.(string)
+		precedence := operatorPrecedence(operator)
+		if precedence < minPrecedence {
+			break
+		}
+		p.cursor++
+
+		// Comparisons may be applied against every element of an array, as in
+		// "a = any(b)"
+		var quantifier string
+		switch {
+		case p.matchKeyword("any"):
+			quantifier = "any"
+		case p.matchKeyword("all"):
+			quantifier = "all"
+		}
+		var b Expression
+		if quantifier != "" {
 			if p.matchToken(LeftParenthesis) == (Token{}) {
-				expression = Expression{Kind: IdentifierExpressionKind, Identifier: item.Value.(string)}
-			} else {
-				//@TODO parse function parameters
-				p.matchToken(RightParenthesis)
-				expression = Expression{
-					Kind:         FunctionCallExpressionKind,
-					FunctionCall: FunctionCall{Name: item.Value.(string)},
-				}
+				return Expression{}
+			}
+			b = p.parseItem()
+			if p.matchToken(RightParenthesis) == (Token{}) {
+				return Expression{}
 			}
-		} else if item.Type == Wildcard {
-			expression = Expression{Kind: IdentifierExpressionKind, Identifier: "*"}
 		} else {
-			expression = Expression{Kind: LiteralExpressionKind, Literal: item.Value}
-		}
-
-		// If item is followed by an operator, then it's a binary expression
-		operator := p.matchToken(Operator)
-		if operator != (Token{}) {
-			return Expression{
-				Kind: BinaryExpressionKind,
-				Binary: &BinaryExpression{
-					A:        expression,
-					B:        p.parseItem(),
-					Operator: operator.Value.(string),
-				},
+			b = p.parseBinaryItem(precedence + 1)
+		}
+		if b == (Expression{}) {
+			if p.err == nil {
+				p.err = fmt.Errorf("expected expression after operator '%s'", operator)
+			}
+			return Expression{}
+		}
+
+		expression = Expression{
+			Kind: BinaryExpressionKind,
+			Binary: &BinaryExpression{
+				A:          expression,
+				B:          b,
+				Operator:   operator,
+				Quantifier: quantifier,
+			},
+		}
+	}
+
+	return expression
+}
+
+func (p *Parser) parseOperand() Expression {
+	var expression Expression
+
+	if p.matchKeyword("cast") {
+		expression = p.parseCast()
+	} else if p.matchOperator("-") {
+		return p.parseNegation()
+	} else {
+		expression = p.parseValue()
+	}
+	if expression == (Expression{}) {
+		return expression
+	}
+
+	// Postgres-style casts and array subscripts bind tighter than any binary
+	// operator
+	for {
+		if p.matchToken(DoubleColon) != (Token{}) {
+			columnType := p.parseTypeName()
+			if columnType == "" {
+				return Expression{}
 			}
+			expression = Expression{
+				Kind: CastExpressionKind,
+				Cast: &CastExpression{Expression: expression, Type: columnType},
+			}
+		} else if p.matchToken(LeftBracket) != (Token{}) {
+			index := p.parseItem()
+			if index == (Expression{}) || p.matchToken(RightBracket) == (Token{}) {
+				return Expression{}
+			}
+			expression = Expression{
+				Kind:   BinaryExpressionKind,
+				Binary: &BinaryExpression{A: expression, B: index, Operator: "[]"},
+			}
+		} else {
+			break
+		}
+	}
+
+	return expression
+}
+
+// parseNegation parses the operand of a unary minus, folding it into integer
+// literals and subtracting other operands from zero
+func (p *Parser) parseNegation() Expression {
+	operand := p.parseOperand()
+	if operand == (Expression{}) {
+		return operand
+	}
+	if integer, ok := operand.Literal.(int); ok && operand.Kind == LiteralExpressionKind {
+		return Expression{Kind: LiteralExpressionKind, Literal: -integer}
+	}
+	return Expression{
+		Kind:   BinaryExpressionKind,
+		Binary: &BinaryExpression{A: Expression{Kind: LiteralExpressionKind, Literal: 0}, B: operand, Operator: "-"},
+	}
+}
+
+func (p *Parser) parseCast() Expression {
+	var emptyExpression Expression
+
+	if p.matchToken(LeftParenthesis) == (Token{}) {
+		return emptyExpression
+	}
+	expression := p.parseItem()
+	if expression == (Expression{}) || !p.matchKeyword("as") {
+		return emptyExpression
+	}
+	columnType := p.parseTypeName()
+	if columnType == "" || p.matchToken(RightParenthesis) == (Token{}) {
+		return emptyExpression
+	}
+
+	return Expression{
+		Kind: CastExpressionKind,
+		Cast: &CastExpression{Expression: expression, Type: columnType},
+	}
+}
+
+func (p *Parser) parseArray() Expression {
+	var elements []Expression
+
+	if p.matchToken(LeftBracket) == (Token{}) {
+		return Expression{}
+	}
+	for {
+		if p.matchToken(RightBracket) != (Token{}) {
+			break
+		}
+
+		element := p.parseItem()
+		if element == (Expression{}) {
+			return Expression{}
+		}
+		elements = append(elements, element)
+
+		p.matchToken(Comma)
+	}
+
+	return Expression{Kind: ArrayExpressionKind, Array: &elements}
+}
+
+func (p *Parser) parseValue() Expression {
+	var expression Expression
+
+	if p.matchKeyword("array") {
+		return p.parseArray()
+	}
+	if p.matchKeyword("null") {
+		return Expression{Kind: NullExpressionKind}
+	}
+
+	item := p.matchToken(Identifier, Wildcard, Number, String, HexString)
+	if item == (Token{}) {
+		return expression
+	}
+
+	if item.Type == Identifier {
+		if p.matchToken(LeftParenthesis) == (Token{}) {
+			expression = Expression{Kind: IdentifierExpressionKind, Identifier: item.Value.(string)}
 		} else {
-			return expression
+			params, ok := p.parseFunctionParams()
+			if !ok {
+				return Expression{}
+			}
+			expression = Expression{
+				Kind:         FunctionCallExpressionKind,
+				FunctionCall: FunctionCall{Name: item.Value.(string), Params: &params},
+			}
+		}
+	} else if item.Type == Wildcard {
+		expression = Expression{Kind: IdentifierExpressionKind, Identifier: "*"}
+	} else {
+		expression = Expression{Kind: LiteralExpressionKind, Literal: item.Value}
+	}
+
+	return expression
+}
+
+func (p *Parser) parseTypeName() string {
+	if p.cursor >= len(p.tokens) {
+		return ""
+	}
+	token := p.tokens[p.cursor]
+	columnType, _ := token.Value.(string)
+	switch token.Type {
+	case Keyword:
+		if columnTypeFromString(columnType) == UnknownColumnType {
+			return ""
+		}
+	case Identifier:
+	default:
+		return ""
+	}
+	p.cursor++
+	// Array types are written as their element type followed by "[]"
+	if p.cursor+1 < len(p.tokens) &&
+		p.tokens[p.cursor].Type == LeftBracket &&
+		p.tokens[p.cursor+1].Type == RightBracket {
+		p.cursor += 2
+		columnType += "[]"
+	}
+	// User-defined types are resolved once the statement is executed
+	if token.Type == Identifier {
+		return columnType
+	}
+	return columnTypeToString(columnTypeFromString(columnType))
+}
+
+// parseFunctionParams parses the arguments of a function call up to its
+// closing parenthesis, returning false when they are not valid
+func (p *Parser) parseFunctionParams() ([]Expression, bool) {
+	var params []Expression
+
+	if p.matchToken(RightParenthesis) != (Token{}) {
+		return params, true
+	}
+	for {
+		param := p.parseItem()
+		if param == (Expression{}) {
+			return nil, false
+		}
+		params = append(params, param)
+
+		if p.matchToken(RightParenthesis) != (Token{}) {
+			return params, true
+		}
+		if p.matchToken(Comma) == (Token{}) {
+			return nil, false
 		}
 	}
 }
 
-func (p *Parser) parseSelectTable() (string, error) {
+// parseSelectTable parses the relation a select reads from, or the function
+// returning its rows. From is optional, in which case neither is returned
+func (p *Parser) parseSelectTable() (string, *FunctionCall, error) {
 	if !p.matchKeyword("from") {
-		return "", errors.New("expected 'from' after select items")
+		return "", nil, nil
 	}
 	table := p.matchToken(Identifier)
 	if table == (Token{}) {
-		return "", errors.New("expected identifier after 'from'")
+		return "", nil, errors.New("expected identifier after 'from'")
+	}
+	if p.matchToken(LeftParenthesis) == (Token{}) {
+		return table.Value.(string), nil, nil
 	}
-	return table.Value.(string), nil
+	params, ok := p.parseFunctionParams()
+	if !ok {
+		return "", nil, fmt.Errorf("expected valid arguments for function %s", table.Value)
+	}
+	return "", &FunctionCall{Name: table.Value.(string), Params: &params}, nil
 }
 
 func (p *Parser) parseOrderBy() (OrderBy, error) {
@@ -388,10 +1811,10 @@ func (p *Parser) parseInt(keywords string) (int, error) {
 
 func (p *Parser) matchKeyword(value string) bool {
 	var str string
-	if p.cursor >= len(p.tokens) {
+	n := len(strings.Split(value, " "))
+	if p.cursor+n > len(p.tokens) {
 		return false
 	}
-	n := len(strings.Split(value, " "))
 	for i := 0; i < n; i++ {
 		if p.tokens[p.cursor+i].Type != Keyword {
 			return false
@@ -408,6 +1831,28 @@ func (p *Parser) matchKeyword(value string) bool {
 	return false
 }
 
+// matchWord matches an identifier with the given value, for words that only
+// act as keywords in specific places, such as "type" in "create type"
+func (p *Parser) matchWord(value string) bool {
+	if p.cursor >= len(p.tokens) ||
+		p.tokens[p.cursor].Type != Identifier ||
+		p.tokens[p.cursor].Value != value {
+		return false
+	}
+	p.cursor++
+	return true
+}
+
+func (p *Parser) matchOperator(value string) bool {
+	if p.cursor >= len(p.tokens) ||
+		p.tokens[p.cursor].Type != Operator ||
+		p.tokens[p.cursor].Value != value {
+		return false
+	}
+	p.cursor++
+	return true
+}
+
 func (p *Parser) matchToken(tokenTypes ...TokenType) Token {
 	var token Token
 	if p.cursor >= len(p.tokens) {
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "missing right operand in select items",
+			input:   "select id + from t",
+			wantErr: "expected expression after operator '+'",
+		},
+		{
+			name:    "missing right operand in where",
+			input:   "select id from t where id = 1 +",
+			wantErr: "expected expression after operator '+'",
+		},
+		{
+			name:    "missing json path",
+			input:   "select doc -> from t",
+			wantErr: "expected expression after operator '->'",
+		},
+		{
+			name:    "missing right operand in select items without from",
+			input:   "select 1 +",
+			wantErr: "expected expression after operator '+'",
+		},
+		{
+			name:    "missing right operand before another select item",
+			input:   "select 1 +, 2",
+			wantErr: "expected expression after operator '+'",
+		},
+		{
+			name:    "missing right operand in function arguments",
+			input:   "select length(1 +)",
+			wantErr: "expected expression after operator '+'",
+		},
+		{
+			name:    "missing select items",
+			input:   "select",
+			wantErr: "expected valid expression after 'select'",
+		},
+		{
+			name:    "missing select item after a comma",
+			input:   "select 1,",
+			wantErr: "expected valid expression after ','",
+		},
+		{
+			name:    "missing returning items",
+			input:   "delete from t returning",
+			wantErr: "expected items after 'returning'",
+		},
+		{
+			name:    "invalid arguments of a function in from",
+			input:   "select * from generate_series(1, 3,)",
+			wantErr: "expected valid arguments for function generate_series",
+		},
+		{
+			name:    "trailing tokens",
+			input:   "select 1 2",
+			wantErr: "syntax error at or near '2'",
+		},
+		{
+			name:    "tokens after a semicolon",
+			input:   "select 1; select 2",
+			wantErr: "syntax error at or near 'select'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseStatement(test.input)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PublicSchema is the schema of relations created without a schema name when
+// the search path is not set. Its relations are stored without a schema name
+const PublicSchema = "public"
+
+// relationName returns the name under which a relation of a schema is stored
+func relationName(schema string, name string) string {
+	if schema == PublicSchema {
+		return name
+	}
+	return schema + "." + name
+}
+
+// splitName returns the schema and name of a stored relation name
+func splitName(name string) (string, string) {
+	if schema, relation, ok := strings.Cut(name, "."); ok {
+		return schema, relation
+	}
+	return PublicSchema, name
+}
+
+func (s Storage) CreateSchema(schemaName string) error {
+	exists, err := s.schemaExists(schemaName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("schema %s already exists", schemaName)
+	}
+	return s.addCatalogEntry(SchemaCatalogEntry, schemaName, NewByteStreamBuffer())
+}
+
+// DropSchema drops an empty schema
+func (s Storage) DropSchema(schemaName string) error {
+	if schemaName == PublicSchema {
+		return fmt.Errorf("cannot drop schema %s", schemaName)
+	}
+	kinds := []CatalogEntryKind{TableCatalogEntry, ViewCatalogEntry, IndexCatalogEntry, SequenceCatalogEntry}
+	for _, kind := range kinds {
+		for name := range s.catalogEntries(kind) {
+			if schema, _ := splitName(name); schema == schemaName {
+				return fmt.Errorf("cannot drop schema %s because relation %s depends on it", schemaName, name)
+			}
+		}
+	}
+
+	found, err := s.removeCatalogEntry(SchemaCatalogEntry, schemaName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("schema %s does not exist", schemaName)
+	}
+	return nil
+}
+
+func (s Storage) schemaExists(schemaName string) (bool, error) {
+	if schemaName == PublicSchema {
+		return true, nil
+	}
+	_, _, found, err := s.findCatalogEntry(SchemaCatalogEntry, schemaName)
+	return found, err
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+type Sequence struct {
+	Name      string
+	Start     int
+	Increment int
+	// LastValue is the value returned by the latest call to nextval, or the
+	// start value when Called is false
+	LastValue int
+	Called    bool
+	// OwnerTable and OwnerColumn are set for sequences backing identity
+	// columns, which are dropped along with their column
+	OwnerTable  string
+	OwnerColumn string
+}
+
+// sequenceName follows Postgres conventions to name the sequences backing
+// identity columns
+func sequenceName(tableName string, columnName string) string {
+	return tableName + "_" + columnName + "_seq"
+}
+
+func (s Storage) CreateSequence(sequence Sequence) error {
+	exists, err := s.relationExists(sequence.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("relation %s already exists", sequence.Name)
+	}
+	if sequence.Increment == 0 {
+		return fmt.Errorf("increment of sequence %s must not be zero", sequence.Name)
+	}
+	if !inSequenceBounds(sequence.Start) || !inSequenceBounds(sequence.Increment) {
+		return fmt.Errorf("start value and increment of sequence %s must be between %d and %d", sequence.Name, math.MinInt32, math.MaxInt32)
+	}
+	sequence.LastValue = sequence.Start
+	return s.writeSequence(sequence)
+}
+
+func (s Storage) DropSequence(sequenceName string) error {
+	found, err := s.removeCatalogEntry(SequenceCatalogEntry, sequenceName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("sequence %s does not exist", sequenceName)
+	}
+	return nil
+}
+
+func (s Storage) GetSequence(sequenceName string) (Sequence, error) {
+	buf, _, found, err := s.findCatalogEntry(SequenceCatalogEntry, sequenceName)
+	if err != nil {
+		return Sequence{}, err
+	}
+	if !found {
+		return Sequence{}, fmt.Errorf("sequence %s does not exist", sequenceName)
+	}
+	return readSequence(sequenceName, &buf), nil
+}
+
+// NextValue advances a sequence, persisting its new state before returning
+// the value. Sequences don't cycle, so advancing past the bounds of an integer
+// fails
+func (s Storage) NextValue(sequenceName string) (int, error) {
+	sequence, err := s.GetSequence(sequenceName)
+	if err != nil {
+		return 0, err
+	}
+	if sequence.Called {
+		next := sequence.LastValue + sequence.Increment
+		if next > math.MaxInt32 {
+			return 0, fmt.Errorf("nextval: reached maximum value of sequence %s (%d)", sequenceName, math.MaxInt32)
+		}
+		if next < math.MinInt32 {
+			return 0, fmt.Errorf("nextval: reached minimum value of sequence %s (%d)", sequenceName, math.MinInt32)
+		}
+		sequence.LastValue = next
+	}
+	sequence.Called = true
+	return sequence.LastValue, s.writeSequence(sequence)
+}
+
+// SetValue sets the value of a sequence, so the next value follows it
+func (s Storage) SetValue(sequenceName string, value int) error {
+	sequence, err := s.GetSequence(sequenceName)
+	if err != nil {
+		return err
+	}
+	if !inSequenceBounds(value) {
+		return fmt.Errorf("setval: value %d is out of bounds for sequence %s (%d..%d)", value, sequenceName, math.MinInt32, math.MaxInt32)
+	}
+	sequence.LastValue = value
+	sequence.Called = true
+	return s.writeSequence(sequence)
+}
+
+// inSequenceBounds tells whether a value fits into the 4 bytes the state of
+// sequences is stored in
+func inSequenceBounds(value int) bool {
+	return value >= math.MinInt32 && value <= math.MaxInt32
+}
+
+// ownedSequences returns the sequences backing identity columns of a table
+func (s Storage) ownedSequences(tableName string) []Sequence {
+	var sequences []Sequence
+	for name, buf := range s.catalogEntries(SequenceCatalogEntry) {
+		if sequence := readSequence(name, &buf); sequence.OwnerTable == tableName {
+			sequences = append(sequences, sequence)
+		}
+	}
+	return sequences
+}
+
+// writeSequence replaces the state of a sequence in the table definitions page
+func (s Storage) writeSequence(sequence Sequence) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteInt(sequence.Start, IntSize)
+	buf.WriteInt(sequence.Increment, IntSize)
+	buf.WriteInt(sequence.LastValue, IntSize)
+	buf.WriteInt(boolToInt(sequence.Called), SmallIntSize)
+	buf.WriteString(sequence.OwnerTable)
+	buf.WriteString(sequence.OwnerColumn)
+
+	if _, err := s.removeCatalogEntry(SequenceCatalogEntry, sequence.Name); err != nil {
+		return err
+	}
+	return s.addCatalogEntry(SequenceCatalogEntry, sequence.Name, buf)
+}
+
+func readSequence(name string, buf *ByteStreamBuffer) Sequence {
+	return Sequence{
+		Name:        name,
+		Start:       int(int32(buf.ReadInt(IntSize))),
+		Increment:   int(int32(buf.ReadInt(IntSize))),
+		LastValue:   int(int32(buf.ReadInt(IntSize))),
+		Called:      buf.ReadInt(SmallIntSize) == 1,
+		OwnerTable:  buf.ReadString(),
+		OwnerColumn: buf.ReadString(),
+	}
+}
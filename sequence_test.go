@@ -0,0 +1,126 @@
+package main
+
+import "testing"
+
+func TestSequences(t *testing.T) {
+	setup := []string{
+		"create table t (id integer)",
+		"insert into t (id) values (1)",
+		"create sequence s start with 10 increment by 5",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "nextval advances the sequence",
+			statement: "select nextval('s') from t",
+			query:     "select nextval('s'), currval('s') from t",
+			want:      [][]string{{"15", "15"}},
+		},
+		{
+			name:      "currval before nextval",
+			statement: "select currval('s') from t",
+			wantErr:   "currval of sequence s is not yet defined in this session",
+		},
+		{
+			name:      "setval sets the latest value",
+			statement: "select setval('s', 100) from t",
+			query:     "select nextval('s') from t",
+			want:      [][]string{{"105"}},
+		},
+		{
+			name:      "setval out of bounds",
+			statement: "select setval('s', 3000000000) from t",
+			wantErr:   "setval: value 3000000000 is out of bounds for sequence s (-2147483648..2147483647)",
+		},
+		{
+			name:      "start value out of bounds",
+			statement: "create sequence x start 3000000000",
+			wantErr:   "start value and increment of sequence x must be between -2147483648 and 2147483647",
+		},
+		{
+			name:      "duplicate sequences",
+			statement: "create sequence t",
+			wantErr:   "relation t already exists",
+		},
+		{
+			name:      "dropped sequences",
+			statement: "drop sequence s",
+			query:     "select id from t",
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "unknown sequences",
+			statement: "select nextval('nope') from t",
+			wantErr:   "sequence nope does not exist",
+		},
+		{
+			name:      "sequence functions in checks",
+			statement: "create table u (n integer check (n > nextval('s')))",
+			wantErr:   "sequence function nextval is not allowed in check constraints",
+		},
+		{
+			name:      "sequence functions in defaults",
+			statement: "create table u (n integer default nextval('s'))",
+		},
+	})
+}
+
+func TestSequenceBounds(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create table t (id integer)",
+		"insert into t (id) values (1)",
+		"create sequence s start with 2147483646",
+		"select nextval('s') from t",
+		"select nextval('s') from t",
+	)
+	err := exec(backend, "select nextval('s') from t")
+	if want := "nextval: reached maximum value of sequence s (2147483647)"; err == nil || err.Error() != want {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+	if got := query(t, backend, "select currval('s') from t"); got[0][0] != "2147483647" {
+		t.Errorf("got current value %v, want 2147483647", got)
+	}
+}
+
+func TestSerialAndIdentityColumns(t *testing.T) {
+	setup := []string{
+		"create table u (id serial primary key, n text, g integer generated always as identity)",
+		"insert into u (n) values ('a')",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:      "values are generated",
+			statement: "insert into u (n) values ('b')",
+			query:     "select id, n, g from u",
+			want:      [][]string{{"1", "a", "1"}, {"2", "b", "2"}},
+		},
+		{
+			name:      "serial values may be given",
+			statement: "insert into u (id, n) values (5, 'b')",
+			query:     "select id, n, g from u",
+			want:      [][]string{{"1", "a", "1"}, {"5", "b", "2"}},
+		},
+		{
+			name:      "generated always values may not be given",
+			statement: "insert into u (n, g) values ('c', 3)",
+			wantErr:   "cannot insert a value into column g, which is generated always",
+		},
+		{
+			name:      "sequences are named after their column",
+			statement: "select setval('u_id_seq', 10) from u",
+			query:     "select nextval('u_id_seq') from u",
+			want:      [][]string{{"11"}},
+		},
+	})
+}
+
+func TestOwnedSequencesAreDropped(t *testing.T) {
+	backend := newTestBackend(t)
+	mustExec(t, backend,
+		"create table u (id serial, n text)",
+		"drop table u",
+	)
+	if _, err := backend.storage.GetSequence("u_id_seq"); err == nil {
+		t.Error("expected the sequence of a dropped table to be dropped")
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"math"
 	"os"
+	"path/filepath"
+	"strings"
 
 	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -28,19 +30,53 @@ type ColumnType uint
 const (
 	Int ColumnType = iota
 	Text
+	Blob
+	Json
+	Uuid
+	Enum
 	UnknownColumnType
 )
 
+// ArrayColumnType is combined with the type of the elements of an array column
+const ArrayColumnType ColumnType = 1 << 8
+
 type Storage struct {
 	filePath string
 	pageSize int
 	cache    *lru.Cache[int, []byte]
+	// indexCache holds the keys of indexes that have already been read
+	indexCache map[string]map[string]bool
+	// shared is only set for temporary storages, which look up types and
+	// relation names in the shared storage as well
+	shared *Storage
+	// journal records the pages changed by the statement being run
+	journal *pageJournal
 }
 
 type TableDefinition struct {
 	Name          string
 	Columns       []ColumnDefinition
 	ColumnIndexes map[string]int
+	// Version is increased whenever columns are added or dropped. Rows are
+	// prefixed with the version they were written with, and are decoded with
+	// the columns that existed at that version
+	Version       int
+	StoredColumns []StoredColumn
+}
+
+// StoredColumn is a column as laid out in rows, including dropped columns
+type StoredColumn struct {
+	ColumnDefinition
+	AddedIn   int
+	DroppedIn int
+	// Missing is the value of the column for rows written before it was added
+	Missing interface{}
+}
+
+// existsIn tells whether rows written with the given table version include
+// a value for the column
+func (c StoredColumn) existsIn(version int) bool {
+	return c.AddedIn <= version && (c.DroppedIn == 0 || c.DroppedIn > version)
 }
 
 type Row struct {
@@ -52,132 +88,916 @@ type RowValue struct {
 	Value  interface{}
 }
 
-func NewStorage() Storage {
+// FormatVersion identifies the layout of data files. Files written with a
+// different layout are refused rather than misread
+const FormatVersion = 2
+
+func NewStorage() (Storage, error) {
+	return newStorage("data")
+}
+
+// NewTemporaryStorage creates a storage in a scratch directory, for relations
+// that only live as long as a session. The directory is removed by Close
+func NewTemporaryStorage(shared Storage) (Storage, error) {
+	dir, err := os.MkdirTemp("", "dbms")
+	if err != nil {
+		return Storage{}, err
+	}
+	s, err := newStorage(filepath.Join(dir, "data"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return s, err
+	}
+	s.shared = &shared
+	return s, nil
+}
+
+// Close removes the files of a temporary storage
+func (s Storage) Close() error {
+	if s.shared == nil {
+		return nil
+	}
+	return os.RemoveAll(filepath.Dir(s.filePath))
+}
+
+func newStorage(filePath string) (Storage, error) {
 	cache, _ := lru.New[int, []byte](1000)
 	s := Storage{
-		filePath: "data",
-		pageSize: 16 * 1024,
-		cache:    cache,
+		filePath:   filePath,
+		pageSize:   16 * 1024,
+		cache:      cache,
+		indexCache: make(map[string]map[string]bool),
+		journal:    &pageJournal{},
 	}
 	if _, err := os.Stat(s.filePath); errors.Is(err, os.ErrNotExist) {
 		// Create file if not exists
-		os.Create(s.filePath)
+		file, err := os.Create(s.filePath)
+		if err != nil {
+			return s, err
+		}
+		file.Close()
 		// Add two pages: table definitions and page directory
 		s.createPage("table_definitions", false)
 		s.createPage("page_directory", false)
+		// The format version is the first entry of the catalog
+		buf := NewByteStreamBuffer()
+		buf.WriteInt(FormatVersion, IntSize)
+		if err := s.addCatalogEntry(FormatCatalogEntry, "format", buf); err != nil {
+			return s, err
+		}
 	}
-	return s
-}
 
-func (s Storage) CreateTable(tableName string, columns []ColumnDefinition) error {
-	// Create buffer with column definitions
-	cd := NewByteStreamBuffer()
-	for _, column := range columns {
-		cd.WriteString(column.Name)
-		cd.WriteInt(int(columnTypeFromString(column.Type)), SmallIntSize)
+	version, err := s.formatVersion()
+	if err != nil {
+		return s, err
+	}
+	if version != FormatVersion {
+		return s, fmt.Errorf("data file %s has format version %d, expected %d", s.filePath, version, FormatVersion)
 	}
+	return s, nil
+}
 
-	// Create buffer to be written into page
-	buf := NewByteStreamBuffer()
-	buf.WriteString(tableName)
-	buf.WriteInt(len(cd.Bytes()), IntSize)
-	buf.Concat(cd)
+// formatVersion reads the format version from the first catalog entry. Files
+// written before versions were recorded have version 0
+func (s Storage) formatVersion() (int, error) {
+	buf, err := s.readPage(TableDefinitionsIndex)
+	if err != nil {
+		return 0, err
+	}
+	if buf.ReadInt(IntSize) <= int(IntSize) {
+		return 0, nil
+	}
+	if CatalogEntryKind(buf.ReadInt(SmallIntSize)) != FormatCatalogEntry || buf.ReadString() != "format" {
+		return 0, nil
+	}
+	buf.ReadInt(IntSize)
+	return buf.ReadInt(IntSize), nil
+}
 
-	// Write into page
-	err := s.appendToPage(buf.Bytes(), int(TableDefinitionsIndex))
+func (s Storage) CreateTable(tableName string, columns []ColumnDefinition, constraints []TableConstraint) error {
+	exists, err := s.relationExists(tableName)
 	if err != nil {
 		return err
 	}
+	if exists {
+		return fmt.Errorf("relation %s already exists", tableName)
+	}
+
+	// Identity columns take their values from a sequence owned by the table
+	var sequences []Sequence
+	for i := range columns {
+		if columns[i].Identity == NoIdentity {
+			continue
+		}
+		if columns[i].Type != "integer" {
+			return fmt.Errorf("identity column %s must be of type integer", columns[i].Name)
+		}
+		sequence := Sequence{
+			Name:        sequenceName(tableName, columns[i].Name),
+			Start:       1,
+			Increment:   1,
+			OwnerTable:  tableName,
+			OwnerColumn: columns[i].Name,
+		}
+		exists, err := s.relationExists(sequence.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("relation %s already exists", sequence.Name)
+		}
+		params := []Expression{{Kind: LiteralExpressionKind, Literal: sequence.Name}}
+		columns[i].Default = Expression{
+			Kind:         FunctionCallExpressionKind,
+			FunctionCall: FunctionCall{Name: "nextval", Params: &params},
+		}
+		columns[i].NotNull = true
+		sequences = append(sequences, sequence)
+	}
+
+	// Primary key columns cannot be null
+	for _, constraint := range constraints {
+		if constraint.Kind != PrimaryKeyConstraint {
+			continue
+		}
+		for i := range columns {
+			if slices.Contains(constraint.Columns, columns[i].Name) {
+				columns[i].NotNull = true
+			}
+		}
+	}
+
+	tableDefinition := TableDefinition{Name: tableName, ColumnIndexes: make(map[string]int)}
+	for _, column := range columns {
+		if _, ok := tableDefinition.ColumnIndexes[column.Name]; ok {
+			return fmt.Errorf("column %s specified more than once", column.Name)
+		}
+		tableDefinition.ColumnIndexes[column.Name] = len(tableDefinition.Columns)
+		tableDefinition.Columns = append(tableDefinition.Columns, column)
+		tableDefinition.StoredColumns = append(tableDefinition.StoredColumns, StoredColumn{
+			ColumnDefinition: column,
+		})
+	}
+
+	// Validate constraints before anything is written
+	var indexes []IndexDefinition
+	indexNames := make(map[string]bool)
+	for _, constraint := range constraints {
+		if constraint.Kind == ForeignKeyConstraint {
+			continue
+		}
+		index := IndexDefinition{
+			Name:    indexName(tableName, constraint),
+			Table:   tableName,
+			Columns: constraint.Columns,
+			Unique:  true,
+			Primary: constraint.Kind == PrimaryKeyConstraint,
+		}
+		if index.Primary && indexNames[index.Name] {
+			return fmt.Errorf("multiple primary keys for table %s are not allowed", tableName)
+		}
+		for _, column := range index.Columns {
+			if _, ok := tableDefinition.ColumnIndexes[column]; !ok {
+				return fmt.Errorf("column %s named in key does not exist", column)
+			}
+		}
+		exists, err := s.relationExists(index.Name)
+		if err != nil {
+			return err
+		}
+		if exists || indexNames[index.Name] {
+			return fmt.Errorf("relation %s already exists", index.Name)
+		}
+		indexNames[index.Name] = true
+		indexes = append(indexes, index)
+	}
 
+	// Foreign keys may reference the table being created
+	var foreignKeys []ForeignKeyDefinition
+	for _, constraint := range constraints {
+		if constraint.Kind != ForeignKeyConstraint {
+			continue
+		}
+		referencedTable, referencedIndexes := tableDefinition, indexes
+		if constraint.References != tableName {
+			if referencedTable, err = s.GetTableDefinition(constraint.References); err != nil {
+				return err
+			}
+			referencedIndexes = s.tableIndexes(constraint.References)
+		}
+		foreignKey, err := newForeignKey(tableDefinition, constraint, referencedTable, referencedIndexes)
+		if err != nil {
+			return err
+		}
+		_, _, found, err := s.findCatalogEntry(ForeignKeyCatalogEntry, foreignKey.Name)
+		if err != nil {
+			return err
+		}
+		if found || slices.ContainsFunc(foreignKeys, func(f ForeignKeyDefinition) bool { return f.Name == foreignKey.Name }) {
+			return fmt.Errorf("constraint %s already exists", foreignKey.Name)
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+
+	if err := s.writeTableDefinition(tableDefinition); err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if err := s.createIndex(index); err != nil {
+			return err
+		}
+	}
+	for _, foreignKey := range foreignKeys {
+		if err := s.writeForeignKeyDefinition(foreignKey); err != nil {
+			return err
+		}
+	}
+	for _, sequence := range sequences {
+		if err := s.CreateSequence(sequence); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s Storage) InsertInto(tableToInsert string, values []RowValue) error {
-	var maxPageIndex = -1
+// AddColumn adds a column to a table without rewriting its rows. Rows written
+// before the column was added will read the missing value instead
+func (s Storage) AddColumn(tableName string, column ColumnDefinition, missing interface{}) error {
+	tableDefinition, err := s.GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+	if _, ok := tableDefinition.ColumnIndexes[column.Name]; ok {
+		return fmt.Errorf("column %s of table %s already exists", column.Name, tableName)
+	}
 
-	tableDefinition, err := s.GetTableDefinition(tableToInsert)
+	tableDefinition.Version++
+	tableDefinition.StoredColumns = append(tableDefinition.StoredColumns, StoredColumn{
+		ColumnDefinition: column,
+		AddedIn:          tableDefinition.Version,
+		Missing:          missing,
+	})
 
-	// Write values from row into a buffer
-	buf := NewByteStreamBuffer()
+	return s.writeTableDefinition(tableDefinition)
+}
+
+// DropColumn removes a column from a table without rewriting its rows. Values
+// of the column are kept in existing rows, and skipped when they are read
+func (s Storage) DropColumn(tableName string, columnName string) error {
+	tableDefinition, err := s.GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+	if _, ok := tableDefinition.ColumnIndexes[columnName]; !ok {
+		return fmt.Errorf("column %s of table %s does not exist", columnName, tableName)
+	}
+	if len(tableDefinition.Columns) == 1 {
+		return fmt.Errorf("cannot drop the only column of table %s", tableName)
+	}
 	for _, column := range tableDefinition.Columns {
-		var value interface{}
-		for i := range values {
-			if values[i].Column == column.Name {
-				value = values[i].Value
+		if column.Name != columnName && slices.Contains(expressionIdentifiers(column.Generated), columnName) {
+			return fmt.Errorf(
+				"cannot drop column %s of table %s because generated column %s depends on it",
+				columnName,
+				tableName,
+				column.Name,
+			)
+		}
+	}
+	for _, foreignKey := range s.referencingForeignKeys(tableName) {
+		if slices.Contains(foreignKey.ReferencedColumns, columnName) {
+			return fmt.Errorf(
+				"cannot drop column %s of table %s because foreign key %s on table %s depends on it",
+				columnName,
+				tableName,
+				foreignKey.Name,
+				foreignKey.Table,
+			)
+		}
+	}
+
+	tableDefinition.Version++
+	for i := range tableDefinition.StoredColumns {
+		column := &tableDefinition.StoredColumns[i]
+		if column.Name == columnName && column.DroppedIn == 0 {
+			column.DroppedIn = tableDefinition.Version
+		}
+		// Check constraints referencing the column are dropped along with it
+		if slices.Contains(expressionIdentifiers(column.Check), columnName) {
+			column.Check = Expression{}
+		}
+	}
+
+	// Indexes, foreign keys and sequences including the column are dropped
+	// along with it
+	for _, sequence := range s.ownedSequences(tableName) {
+		if sequence.OwnerColumn == columnName {
+			if err := s.DropSequence(sequence.Name); err != nil {
+				return err
 			}
 		}
-		switch column.Type {
-		case "text":
-			if value == nil {
-				buf.WriteInt(math.MaxInt8, SmallIntSize)
-			} else {
-				buf.WriteString(value.(string))
+	}
+	for _, foreignKey := range s.tableForeignKeys(tableName) {
+		if slices.Contains(foreignKey.Columns, columnName) {
+			if err := s.dropForeignKey(foreignKey); err != nil {
+				return err
 			}
-		case "integer":
-			if value == nil {
-				buf.WriteInt(math.MaxInt32, IntSize)
-			} else {
-				buf.WriteInt(value.(int), IntSize)
+		}
+	}
+	for _, index := range s.tableIndexes(tableName) {
+		if slices.Contains(index.Columns, columnName) {
+			if err := s.dropIndex(index); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.writeTableDefinition(tableDefinition)
+}
+
+func (s Storage) RenameColumn(tableName string, columnName string, newName string) error {
+	tableDefinition, err := s.GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+	if _, ok := tableDefinition.ColumnIndexes[columnName]; !ok {
+		return fmt.Errorf("column %s of table %s does not exist", columnName, tableName)
+	}
+	if _, ok := tableDefinition.ColumnIndexes[newName]; ok {
+		return fmt.Errorf("column %s of table %s already exists", newName, tableName)
+	}
+
+	for i := range tableDefinition.StoredColumns {
+		column := &tableDefinition.StoredColumns[i]
+		if column.Name == columnName && column.DroppedIn == 0 {
+			column.Name = newName
+		}
+		if column.Check != (Expression{}) {
+			column.Check = renameIdentifier(column.Check, columnName, newName)
+		}
+		if column.Generated != (Expression{}) {
+			column.Generated = renameIdentifier(column.Generated, columnName, newName)
+		}
+	}
+
+	for _, index := range s.tableIndexes(tableName) {
+		if i := slices.Index(index.Columns, columnName); i != -1 {
+			index.Columns[i] = newName
+			if err := s.writeIndexDefinition(index); err != nil {
+				return err
+			}
+		}
+	}
+	for _, foreignKey := range s.foreignKeys(func(foreignKey ForeignKeyDefinition) bool {
+		return foreignKey.Table == tableName || foreignKey.References == tableName
+	}) {
+		if foreignKey.Table == tableName {
+			if i := slices.Index(foreignKey.Columns, columnName); i != -1 {
+				foreignKey.Columns[i] = newName
 			}
 		}
+		if foreignKey.References == tableName {
+			if i := slices.Index(foreignKey.ReferencedColumns, columnName); i != -1 {
+				foreignKey.ReferencedColumns[i] = newName
+			}
+		}
+		if err := s.writeForeignKeyDefinition(foreignKey); err != nil {
+			return err
+		}
+	}
+	for _, sequence := range s.ownedSequences(tableName) {
+		if sequence.OwnerColumn == columnName {
+			sequence.OwnerColumn = newName
+			if err := s.writeSequence(sequence); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.writeTableDefinition(tableDefinition)
+}
+
+func (s Storage) RenameTable(tableName string, newName string) error {
+	tableDefinition, err := s.GetTableDefinition(tableName)
+	if err != nil {
+		return err
+	}
+	exists, err := s.relationExists(newName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("relation %s already exists", newName)
+	}
+
+	if _, err := s.removeCatalogEntry(TableCatalogEntry, tableName); err != nil {
+		return err
+	}
+	tableDefinition.Name = newName
+	if err := s.writeTableDefinition(tableDefinition); err != nil {
+		return err
+	}
+	for _, index := range s.tableIndexes(tableName) {
+		index.Table = newName
+		if err := s.writeIndexDefinition(index); err != nil {
+			return err
+		}
+	}
+	for _, foreignKey := range s.foreignKeys(func(foreignKey ForeignKeyDefinition) bool {
+		return foreignKey.Table == tableName || foreignKey.References == tableName
+	}) {
+		if foreignKey.Table == tableName {
+			foreignKey.Table = newName
+		}
+		if foreignKey.References == tableName {
+			foreignKey.References = newName
+		}
+		if err := s.writeForeignKeyDefinition(foreignKey); err != nil {
+			return err
+		}
+	}
+	for _, sequence := range s.ownedSequences(tableName) {
+		sequence.OwnerTable = newName
+		if err := s.writeSequence(sequence); err != nil {
+			return err
+		}
+	}
+	for _, trigger := range s.tableTriggers(tableName) {
+		trigger.Table = newName
+		if err := s.writeTrigger(trigger); err != nil {
+			return err
+		}
+	}
+	return s.renamePages(tableName, newName)
+}
 
+func (s Storage) CreateType(typeName string, labels []string) error {
+	_, _, found, err := s.findCatalogEntry(TypeCatalogEntry, typeName)
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("type %s already exists", typeName)
+	}
+
+	// Create buffer with enum labels
+	buf := NewByteStreamBuffer()
+	buf.WriteInt(len(labels), SmallIntSize)
+	for _, label := range labels {
+		buf.WriteString(label)
 	}
 
-	// Read page directory to find latest page containing data for this table
-	pd, err := s.readPage(int(PageDirectoryIndex))
+	// Write into table definitions page
+	return s.addCatalogEntry(TypeCatalogEntry, typeName, buf)
+}
+
+// DropTable removes a table definition along with its indexes and triggers,
+// and releases their pages to be reused
+func (s Storage) DropTable(tableName string) error {
+	if err := s.dependentForeignKeysError(tableName, "drop"); err != nil {
+		return err
+	}
+	found, err := s.removeCatalogEntry(TableCatalogEntry, tableName)
 	if err != nil {
 		return err
 	}
-	pageLength := pd.ReadInt(IntSize)
-	for pd.Cursor() < pageLength {
-		tableName := pd.ReadString()
-		pageIndex := pd.ReadInt(SmallIntSize)
-		if tableName == tableToInsert {
-			maxPageIndex = pageIndex
+	if !found {
+		return fmt.Errorf("definition for table %s not found", tableName)
+	}
+	for _, foreignKey := range s.tableForeignKeys(tableName) {
+		if err := s.dropForeignKey(foreignKey); err != nil {
+			return err
+		}
+	}
+	for _, index := range s.tableIndexes(tableName) {
+		if err := s.dropIndex(index); err != nil {
+			return err
+		}
+	}
+	for _, sequence := range s.ownedSequences(tableName) {
+		if err := s.DropSequence(sequence.Name); err != nil {
+			return err
+		}
+	}
+	for _, trigger := range s.tableTriggers(tableName) {
+		if err := s.DropTrigger(trigger.Name); err != nil {
+			return err
+		}
+	}
+	return s.releasePages(tableName)
+}
+
+// TruncateTable removes all rows from a table, releasing its pages to be reused
+func (s Storage) TruncateTable(tableName string) error {
+	if _, err := s.GetTableDefinition(tableName); err != nil {
+		return err
+	}
+	if err := s.dependentForeignKeysError(tableName, "truncate"); err != nil {
+		return err
+	}
+	for _, index := range s.tableIndexes(tableName) {
+		if err := s.rebuildIndex(index, make(map[string]bool)); err != nil {
+			return err
+		}
+	}
+	return s.releasePages(tableName)
+}
+
+// InsertRows inserts a batch of rows into a table. Every row is checked
+// before anything is written, and each page of the table and its indexes is
+// filled in memory and written once
+func (s Storage) InsertRows(tableToInsert string, rows [][]RowValue) error {
+	tableDefinition, err := s.GetTableDefinition(tableToInsert)
+	if err != nil {
+		return err
+	}
+	indexes := s.tableIndexes(tableToInsert)
+	existingKeys := make([]map[string]bool, len(indexes))
+	for i, index := range indexes {
+		if existingKeys[i], err = s.indexKeys(index); err != nil {
+			return err
+		}
+	}
+
+	// Check unique constraints against the existing keys and the keys of the
+	// previous rows of the batch
+	var entries [][]byte
+	completeRows := make([][]RowValue, len(rows))
+	batchKeys := make(map[string]map[string]bool)
+	newKeys := make([][]string, len(indexes))
+	for _, index := range indexes {
+		batchKeys[index.Name] = make(map[string]bool)
+	}
+	for r, values := range rows {
+		// Columns without a value take their default
+		var row []RowValue
+		for _, column := range tableDefinition.StoredColumns {
+			if column.DroppedIn != 0 {
+				continue
+			}
+			value := column.Missing
+			for i := range values {
+				if values[i].Column == column.Name {
+					value = values[i].Value
+				}
+			}
+			row = append(row, RowValue{Column: column.Name, Value: value})
+		}
+		completeRows[r] = row
+
+		buf, err := s.encodeRow(tableDefinition, row)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, buf.Bytes())
+
+		for i, index := range indexes {
+			key, indexed, err := checkIndexKey(index, tableDefinition, row, existingKeys[i])
+			if err != nil {
+				return err
+			}
+			if _, _, err := checkIndexKey(index, tableDefinition, row, batchKeys[index.Name]); err != nil {
+				return err
+			}
+			if indexed {
+				batchKeys[index.Name][key] = true
+				newKeys[i] = append(newKeys[i], key)
+			}
 		}
 	}
 
-	if maxPageIndex == -1 {
-		// If a page for the table cannot be found, create a new one
-		maxPageIndex, err = s.createPage(tableToInsert, true)
+	// Check the rows referenced by foreign keys exist. Rows may reference rows
+	// of the same batch
+	for _, foreignKey := range s.tableForeignKeys(tableToInsert) {
+		referencedTable, index, err := s.referencedTable(foreignKey)
 		if err != nil {
 			return err
 		}
-	} else {
-		// Check whether there is enough space on the page, and create a new one if
-		// needed
-		page, err := s.readPage(maxPageIndex)
+		referencedKeys, err := s.indexKeys(index)
 		if err != nil {
 			return err
 		}
-		if usedSpace := page.ReadInt(IntSize); s.pageSize-usedSpace < len(buf.Bytes()) {
-			maxPageIndex, err = s.createPage(tableToInsert, true)
+		for _, row := range completeRows {
+			err := checkForeignKey(foreignKey, referencedTable, index, Row{Values: row}, referencedKeys)
+			if err != nil && foreignKey.References == tableToInsert {
+				err = checkForeignKey(foreignKey, referencedTable, index, Row{Values: row}, batchKeys[index.Name])
+			}
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	// Write buffer into page
-	err = s.appendToPage(buf.Bytes(), maxPageIndex)
-	if err != nil {
+	if err := s.appendToTablePages(tableToInsert, entries); err != nil {
+		return err
+	}
+	for i, index := range indexes {
+		if err := s.addIndexKeys(index, newKeys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RewriteRows replaces every row of a table with the row returned by rewrite,
+// removing rows for which it returns false. Constraints are checked and
+// foreign key actions are applied to other tables before anything is written
+func (s Storage) RewriteRows(tableName string, rewrite func(Row) (Row, bool, error)) error {
+	rewrites := make(map[string]*tableRewrite)
+	if err := s.planRewrite(rewrites, tableName, rewrite); err != nil {
 		return err
 	}
 
+	// Encode every row first, so invalid values don't leave tables half written
+	entries := make(map[string][][]byte)
+	for name, rewrite := range rewrites {
+		for _, row := range rewrite.rows {
+			buf, err := s.encodeRow(rewrite.tableDefinition, row.Values)
+			if err != nil {
+				return err
+			}
+			entries[name] = append(entries[name], buf.Bytes())
+		}
+	}
+
+	for name, rewrite := range rewrites {
+		if err := s.writeRows(name, entries[name]); err != nil {
+			return err
+		}
+		for i, index := range rewrite.indexes {
+			if err := s.rebuildIndex(index, rewrite.indexKeys[i]); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (s Storage) TableRows(tableName string) func(yield func(int, Row) bool) {
-	var pages []int
+// tableRewrite holds the rows a table will contain once a rewrite is applied,
+// along with the keys of its indexes
+type tableRewrite struct {
+	tableDefinition TableDefinition
+	rows            []Row
+	indexes         []IndexDefinition
+	indexKeys       []map[string]bool
+}
+
+// planRewrite applies rewrite to the rows of a table, or to the rows planned
+// for it by a previous call. Rows referencing keys that are removed from the
+// table are then rewritten according to the actions of their foreign keys
+func (s Storage) planRewrite(rewrites map[string]*tableRewrite, tableName string, rewrite func(Row) (Row, bool, error)) error {
+	current, ok := rewrites[tableName]
+	if !ok {
+		tableDefinition, err := s.GetTableDefinition(tableName)
+		if err != nil {
+			return err
+		}
+		current = &tableRewrite{tableDefinition: tableDefinition, indexes: s.tableIndexes(tableName)}
+		for _, row := range s.TableRows(tableName) {
+			current.rows = append(current.rows, row)
+		}
+		rewrites[tableName] = current
+	}
+	tableDefinition, indexes := current.tableDefinition, current.indexes
 
-	pd, _ := s.readPage(int(PageDirectoryIndex))
+	oldKeys := make([]map[string]bool, len(indexes))
+	newKeys := make([]map[string]bool, len(indexes))
+	deletedKeys := make([]map[string]bool, len(indexes))
+	for i := range indexes {
+		oldKeys[i] = make(map[string]bool)
+		newKeys[i] = make(map[string]bool)
+		deletedKeys[i] = make(map[string]bool)
+	}
 
-	// Get pages list
-	pageLength := pd.ReadInt(IntSize)
-	for pd.Cursor() < pageLength {
-		table := pd.ReadString()
-		pageIndex := pd.ReadInt(SmallIntSize)
-		if table == tableName {
-			pages = append(pages, pageIndex)
+	var rows []Row
+	for _, row := range current.rows {
+		for i, index := range indexes {
+			if key, ok, _ := indexKey(index, tableDefinition, row.Values); ok {
+				oldKeys[i][key] = true
+			}
+		}
+		newRow, keep, err := rewrite(row)
+		if err != nil {
+			return err
+		}
+		if !keep {
+			for i, index := range indexes {
+				if key, ok, _ := indexKey(index, tableDefinition, row.Values); ok {
+					deletedKeys[i][key] = true
+				}
+			}
+			continue
+		}
+		for i, index := range indexes {
+			key, ok, err := checkIndexKey(index, tableDefinition, newRow.Values, newKeys[i])
+			if err != nil {
+				return err
+			}
+			if ok {
+				newKeys[i][key] = true
+			}
+		}
+		rows = append(rows, newRow)
+	}
+	current.rows = rows
+	current.indexKeys = newKeys
+
+	// Apply the actions of foreign keys referencing removed keys
+	for _, foreignKey := range s.referencingForeignKeys(tableName) {
+		i := slices.IndexFunc(indexes, func(index IndexDefinition) bool {
+			_, ok := referencedIndex(foreignKey, []IndexDefinition{index})
+			return ok
+		})
+		if i == -1 {
+			continue
+		}
+		removedKeys := make(map[string]bool)
+		for key := range oldKeys[i] {
+			if !newKeys[i][key] {
+				removedKeys[key] = true
+			}
+		}
+		if len(removedKeys) == 0 {
+			continue
+		}
+		index, foreignKey := indexes[i], foreignKey
+		err := s.planRewrite(rewrites, foreignKey.Table, func(row Row) (Row, bool, error) {
+			key, ok, err := foreignKeyValue(foreignKey, tableDefinition, index, row)
+			if err != nil || !ok || !removedKeys[key] {
+				return row, true, err
+			}
+			// Keys changed by an update are not referenced by any action
+			action := foreignKey.OnDelete
+			if !deletedKeys[i][key] {
+				action = NoAction
+			}
+			switch action {
+			case CascadeAction:
+				return row, false, nil
+			case SetNullAction:
+				values := make([]RowValue, len(row.Values))
+				copy(values, row.Values)
+				for _, column := range foreignKey.Columns {
+					values[rewrites[foreignKey.Table].tableDefinition.ColumnIndexes[column]].Value = nil
+				}
+				return Row{Values: values}, true, nil
+			}
+			return row, false, ConstraintViolationError{
+				Constraint: foreignKey.Name,
+				Message: fmt.Sprintf(
+					"update or delete on table %s violates foreign key constraint %s on table %s",
+					tableName,
+					foreignKey.Name,
+					foreignKey.Table,
+				),
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check not null constraints, as foreign key actions may set columns to null
+	for _, row := range current.rows {
+		for i, column := range tableDefinition.Columns {
+			if column.NotNull && row.Values[i].Value == nil {
+				return notNullViolation(tableName, column.Name)
+			}
+		}
+	}
+
+	// Check the rows referenced by foreign keys exist
+	for _, foreignKey := range s.tableForeignKeys(tableName) {
+		referencedTable, index, err := s.referencedTable(foreignKey)
+		if err != nil {
+			return err
+		}
+		var referencedKeys map[string]bool
+		if referenced, ok := rewrites[foreignKey.References]; ok {
+			referencedKeys = referenced.indexKeys[slices.IndexFunc(referenced.indexes, func(i IndexDefinition) bool {
+				return i.Name == index.Name
+			})]
+		} else if referencedKeys, err = s.indexKeys(index); err != nil {
+			return err
+		}
+		for _, row := range current.rows {
+			if err := checkForeignKey(foreignKey, referencedTable, index, row, referencedKeys); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeRow writes the values of a row, prefixed by the table version and a
+// bitmap of its null values. Rows must fit into a single page
+func (s Storage) encodeRow(tableDefinition TableDefinition, values []RowValue) (ByteStreamBuffer, error) {
+	buf := NewByteStreamBuffer()
+	buf.WriteInt(tableDefinition.Version, SmallIntSize)
+
+	var rowValues []interface{}
+	for _, column := range tableDefinition.Columns {
+		value, err := coerceColumnValue(column, rowValue(values, column.Name))
+		if err != nil {
+			return buf, err
+		}
+		rowValues = append(rowValues, value)
+	}
+	writeNullBitmap(&buf, rowValues)
+
+	for i, column := range tableDefinition.Columns {
+		if rowValues[i] == nil {
+			continue
+		}
+		if err := writeColumnValue(&buf, column, rowValues[i]); err != nil {
+			return buf, err
+		}
+	}
+	if maxSize := s.pageSize - int(IntSize); buf.Length() > maxSize {
+		return buf, fmt.Errorf("row is too big: size %d, maximum size %d", buf.Length(), maxSize)
+	}
+	return buf, nil
+}
+
+// appendToTablePages appends entries into the latest page of a table or
+// index, creating new pages when there is not enough space on it. Pages are
+// filled in memory, and written once they are full
+func (s Storage) appendToTablePages(owner string, entries [][]byte) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Read page directory to find latest page containing data for this owner
+	pages, err := s.tablePages(owner)
+	if err != nil {
+		return err
+	}
+	pageIndex := -1
+	contents := NewByteStreamBuffer()
+	if len(pages) > 0 {
+		pageIndex = pages[len(pages)-1]
+		page, err := s.readPage(pageIndex)
+		if err != nil {
+			return err
+		}
+		pageLength := page.ReadInt(IntSize)
+		contents.WriteFixedBytes(page.Bytes()[IntSize:pageLength])
+	}
+
+	var changed bool
+	for _, entry := range entries {
+		// Create a new page when there is no page, or not enough space on the
+		// latest one
+		if pageIndex == -1 || contents.Length()+len(entry)+int(IntSize) > s.pageSize {
+			if changed {
+				if err := s.writePage(pageIndex, contents.Bytes()); err != nil {
+					return err
+				}
+			}
+			if pageIndex, err = s.createPage(owner, true); err != nil {
+				return err
+			}
+			contents = NewByteStreamBuffer()
 		}
+		contents.WriteFixedBytes(entry)
+		changed = true
 	}
+	return s.writePage(pageIndex, contents.Bytes())
+}
+
+// writeRows replaces all pages of a table or index with pages containing the
+// given entries
+func (s Storage) writeRows(owner string, entries [][]byte) error {
+	if err := s.releasePages(owner); err != nil {
+		return err
+	}
+
+	contents := NewByteStreamBuffer()
+	flush := func() error {
+		pageIndex, err := s.createPage(owner, true)
+		if err != nil {
+			return err
+		}
+		return s.writePage(pageIndex, contents.Bytes())
+	}
+	for _, entry := range entries {
+		if contents.Length()+len(entry)+int(IntSize) > s.pageSize {
+			if err := flush(); err != nil {
+				return err
+			}
+			contents = NewByteStreamBuffer()
+		}
+		contents.WriteFixedBytes(entry)
+	}
+	if contents.Length() > 0 {
+		return flush()
+	}
+	return nil
+}
+
+func (s Storage) TableRows(tableName string) func(yield func(int, Row) bool) {
+	// Get pages list
+	pages, _ := s.tablePages(tableName)
 
 	// Get table definition
 	tableDefinition, _ := s.GetTableDefinition(tableName)
@@ -187,18 +1007,30 @@ func (s Storage) TableRows(tableName string) func(yield func(int, Row) bool) {
 		var rowIndex int
 		for _, pageIndex := range pages {
 			page, _ := s.readPage(pageIndex)
-			pageLength = page.ReadInt(IntSize)
+			pageLength := page.ReadInt(IntSize)
 			for page.Cursor() < pageLength {
 				row := Row{}
-				for _, column := range tableDefinition.Columns {
-					var value interface{}
-					switch column.Type {
-					case "text":
-						value = page.ReadString()
-					case "integer":
-						value = page.ReadInt(IntSize)
+				version := page.ReadInt(SmallIntSize)
+				var stored int
+				for _, column := range tableDefinition.StoredColumns {
+					if column.existsIn(version) {
+						stored++
+					}
+				}
+				nulls := readNullBitmap(&page, stored)
+				var i int
+				for _, column := range tableDefinition.StoredColumns {
+					value := column.Missing
+					if column.existsIn(version) {
+						value = nil
+						if !nulls[i] {
+							value = readColumnValue(&page, column.ColumnDefinition)
+						}
+						i++
+					}
+					if column.DroppedIn == 0 {
+						row.Values = append(row.Values, RowValue{Column: column.Name, Value: value})
 					}
-					row.Values = append(row.Values, RowValue{Column: column.Name, Value: value})
 				}
 				if !yield(rowIndex, row) {
 					return
@@ -211,48 +1043,148 @@ func (s Storage) TableRows(tableName string) func(yield func(int, Row) bool) {
 
 func (s Storage) GetTableDefinition(tableName string) (TableDefinition, error) {
 	var tableDefinition TableDefinition
-	var tdFound bool
-	var tdLength int
 
-	buf, err := s.readPage(int(TableDefinitionsIndex))
+	// Find definition of current table
+	buf, tdLength, found, err := s.findCatalogEntry(TableCatalogEntry, tableName)
 	if err != nil {
 		return tableDefinition, err
 	}
-
-	// Find definition of current table
-	pageLength := buf.ReadInt(IntSize)
-	for buf.Cursor() < pageLength {
-		table := buf.ReadString()
-		tdLength = buf.ReadInt(IntSize)
-		if table == tableName {
-			tdFound = true
-			tableDefinition.Name = table
-			break
-		}
-		buf.Skip(tdLength)
-	}
-
-	if !tdFound {
+	if !found {
 		return tableDefinition, fmt.Errorf("definition for table %s not found", tableName)
 	}
-
+	tableDefinition.Name = tableName
 	tableDefinition.ColumnIndexes = make(map[string]int)
+
 	tdEnd := buf.Cursor() + tdLength
-	i := 0
+	tableDefinition.Version = buf.ReadInt(SmallIntSize)
 	for buf.Cursor() < tdEnd {
-		column := buf.ReadString()
-		tableDefinition.Columns = append(tableDefinition.Columns, ColumnDefinition{
-			Name: column,
-			Type: columnTypeToString(ColumnType(buf.ReadInt(SmallIntSize))),
-		})
-		tableDefinition.ColumnIndexes[column] = i
-		i++
+		column := StoredColumn{ColumnDefinition: ColumnDefinition{Name: buf.ReadString()}}
+		columnType := ColumnType(buf.ReadInt(SmallIntSize))
+		if columnType == Enum {
+			column.Type = buf.ReadString()
+			if column.Enum, err = s.GetEnumType(column.Type); err != nil {
+				return tableDefinition, err
+			}
+		} else {
+			column.Type = columnTypeToString(columnType)
+		}
+		column.AddedIn = buf.ReadInt(SmallIntSize)
+		column.DroppedIn = buf.ReadInt(SmallIntSize)
+		if hasMissing := buf.ReadInt(SmallIntSize); hasMissing == 1 {
+			column.Missing = readColumnValue(&buf, column.ColumnDefinition)
+		}
+		column.NotNull = buf.ReadInt(SmallIntSize) == 1
+		if hasDefault := buf.ReadInt(SmallIntSize); hasDefault == 1 {
+			column.Default = readExpression(&buf)
+		}
+		if hasCheck := buf.ReadInt(SmallIntSize); hasCheck == 1 {
+			column.Check = readExpression(&buf)
+		}
+		if hasGenerated := buf.ReadInt(SmallIntSize); hasGenerated == 1 {
+			column.Generated = readExpression(&buf)
+		}
+		column.Identity = IdentityKind(buf.ReadInt(SmallIntSize))
+		tableDefinition.StoredColumns = append(tableDefinition.StoredColumns, column)
+
+		// Only columns that have not been dropped are visible
+		if column.DroppedIn == 0 {
+			tableDefinition.ColumnIndexes[column.Name] = len(tableDefinition.Columns)
+			tableDefinition.Columns = append(tableDefinition.Columns, column.ColumnDefinition)
+		}
 	}
 
 	return tableDefinition, nil
 }
 
+// writeTableDefinition replaces the definition of a table in the table
+// definitions page
+func (s Storage) writeTableDefinition(tableDefinition TableDefinition) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteInt(tableDefinition.Version, SmallIntSize)
+	for _, column := range tableDefinition.StoredColumns {
+		buf.WriteString(column.Name)
+		columnType := columnTypeFromString(column.Type)
+		if columnType != UnknownColumnType {
+			buf.WriteInt(int(columnType), SmallIntSize)
+		} else {
+			// Columns of user-defined types are followed by the name of the type
+			if _, err := s.GetEnumType(column.Type); err != nil {
+				return err
+			}
+			buf.WriteInt(int(Enum), SmallIntSize)
+			buf.WriteString(column.Type)
+		}
+		buf.WriteInt(column.AddedIn, SmallIntSize)
+		buf.WriteInt(column.DroppedIn, SmallIntSize)
+		if column.Missing == nil {
+			buf.WriteInt(0, SmallIntSize)
+		} else {
+			buf.WriteInt(1, SmallIntSize)
+			if err := writeColumnValue(&buf, column.ColumnDefinition, column.Missing); err != nil {
+				return err
+			}
+		}
+		buf.WriteInt(boolToInt(column.NotNull), SmallIntSize)
+		for _, expression := range []Expression{column.Default, column.Check, column.Generated} {
+			if expression == (Expression{}) {
+				buf.WriteInt(0, SmallIntSize)
+			} else {
+				buf.WriteInt(1, SmallIntSize)
+				writeExpression(&buf, expression)
+			}
+		}
+		buf.WriteInt(int(column.Identity), SmallIntSize)
+	}
+
+	if _, err := s.removeCatalogEntry(TableCatalogEntry, tableDefinition.Name); err != nil {
+		return err
+	}
+	return s.addCatalogEntry(TableCatalogEntry, tableDefinition.Name, buf)
+}
+
+func (s Storage) GetEnumType(typeName string) (*EnumType, error) {
+	if element, ok := strings.CutSuffix(typeName, "[]"); ok {
+		if _, err := s.GetEnumType(element); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("arrays of enum type %s are not supported", element)
+	}
+
+	buf, _, found, err := s.findCatalogEntry(TypeCatalogEntry, typeName)
+	if err != nil {
+		return nil, err
+	}
+	if !found && s.shared != nil {
+		return s.shared.GetEnumType(typeName)
+	}
+	if !found {
+		return nil, fmt.Errorf("type %s does not exist", typeName)
+	}
+
+	enum := &EnumType{Name: typeName}
+	labels := buf.ReadInt(SmallIntSize)
+	for i := 0; i < labels; i++ {
+		enum.Labels = append(enum.Labels, buf.ReadString())
+	}
+
+	return enum, nil
+}
+
 func (s Storage) createPage(tableName string, addToPageDirectory bool) (int, error) {
+	// Reuse pages released by dropped or truncated tables
+	if addToPageDirectory {
+		pageIndex, found, err := s.takeFreePage()
+		if err != nil {
+			return -1, err
+		}
+		if found {
+			if err := s.writePage(pageIndex, nil); err != nil {
+				return -1, err
+			}
+			return pageIndex, s.appendPageDirectoryEntry(tableName, pageIndex)
+		}
+	}
+
 	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_RDWR, 0666)
 	if err != nil {
 		return -1, err
@@ -278,10 +1210,7 @@ func (s Storage) createPage(tableName string, addToPageDirectory bool) (int, err
 
 	// Add to page directory
 	if addToPageDirectory {
-		buf = NewByteStreamBuffer()
-		buf.WriteString(tableName)
-		buf.WriteInt(pageIndex, SmallIntSize)
-		err = s.appendToPage(buf.Bytes(), int(PageDirectoryIndex))
+		err = s.appendPageDirectoryEntry(tableName, pageIndex)
 		if err != nil {
 			return -1, err
 		}
@@ -297,6 +1226,10 @@ func (s Storage) appendToPage(bytes []byte, pageIndex int) error {
 	}
 	defer file.Close()
 
+	if err := s.recordPage(file, pageIndex); err != nil {
+		return err
+	}
+
 	// Read page length, so we can write after this position
 	plBytes := make([]byte, 4)
 	file.ReadAt(plBytes, int64(pageIndex*s.pageSize))
@@ -304,6 +1237,9 @@ func (s Storage) appendToPage(bytes []byte, pageIndex int) error {
 	if pageLength == 0 {
 		pageLength += uint32(IntSize)
 	}
+	if int(pageLength)+len(bytes) > s.pageSize {
+		return fmt.Errorf("contents do not fit into page %d", pageIndex)
+	}
 
 	// Write page length at page's first position
 	wb := NewByteStreamBuffer()
@@ -325,6 +1261,35 @@ func (s Storage) appendToPage(bytes []byte, pageIndex int) error {
 	return nil
 }
 
+// writePage replaces all contents of a page
+func (s Storage) writePage(pageIndex int, contents []byte) error {
+	if len(contents)+int(IntSize) > s.pageSize {
+		return fmt.Errorf("contents do not fit into page %d", pageIndex)
+	}
+
+	file, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := s.recordPage(file, pageIndex); err != nil {
+		return err
+	}
+
+	// Write page length followed by contents
+	buf := NewByteStreamBuffer()
+	buf.WriteInt(len(contents)+int(IntSize), IntSize)
+	buf.WriteFixedBytes(contents)
+	if _, err := file.WriteAt(buf.Bytes(), int64(pageIndex*s.pageSize)); err != nil {
+		return err
+	}
+
+	// Update cache
+	s.cache.Add(pageIndex, buf.Bytes())
+
+	return nil
+}
+
 func (s Storage) readPage(pageIndex int) (ByteStreamBuffer, error) {
 	val, ok := s.cache.Get(pageIndex)
 	if ok {
@@ -347,21 +1312,42 @@ func (s Storage) readPage(pageIndex int) (ByteStreamBuffer, error) {
 }
 
 func columnTypeFromString(columnType string) ColumnType {
+	if strings.HasSuffix(columnType, "[]") {
+		if elementType := columnTypeFromString(strings.TrimSuffix(columnType, "[]")); elementType < UnknownColumnType {
+			return elementType | ArrayColumnType
+		}
+		return UnknownColumnType
+	}
 	switch columnType {
 	case "integer":
 		return Int
 	case "text":
 		return Text
+	case "blob", "bytea":
+		return Blob
+	case "json":
+		return Json
+	case "uuid":
+		return Uuid
 	}
 	return UnknownColumnType
 }
 
 func columnTypeToString(columnType ColumnType) string {
+	if columnType&ArrayColumnType != 0 {
+		return columnTypeToString(columnType&^ArrayColumnType) + "[]"
+	}
 	switch columnType {
 	case Int:
 		return "integer"
 	case Text:
 		return "text"
+	case Blob:
+		return "blob"
+	case Json:
+		return "json"
+	case Uuid:
+		return "uuid"
 	default:
 		return "unknown"
 	}
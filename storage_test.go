@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestStorage(t *testing.T) Storage {
+	t.Helper()
+	s, err := newStorage(filepath.Join(t.TempDir(), "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// tableValues returns the values of every row of a table, in the order of its
+// columns
+func tableValues(s Storage, tableName string) [][]interface{} {
+	var rows [][]interface{}
+	for _, row := range s.TableRows(tableName) {
+		var values []interface{}
+		for _, value := range row.Values {
+			values = append(values, value.Value)
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+func insertValues(tableName string, values ...RowValue) func(Storage) error {
+	return func(s Storage) error {
+		return s.InsertRows(tableName, [][]RowValue{values})
+	}
+}
+
+func TestDecodeRowsAcrossVersions(t *testing.T) {
+	columns := []ColumnDefinition{{Name: "id", Type: "integer"}, {Name: "name", Type: "text"}}
+	tests := []struct {
+		name  string
+		steps []func(Storage) error
+		want  [][]interface{}
+	}{
+		{
+			name: "added column reads its missing value in older rows",
+			steps: []func(Storage) error{
+				insertValues("t", RowValue{"id", 1}, RowValue{"name", "a"}),
+				func(s Storage) error {
+					return s.AddColumn("t", ColumnDefinition{Name: "score", Type: "integer"}, 7)
+				},
+				insertValues("t", RowValue{"id", 2}, RowValue{"name", "b"}, RowValue{"score", 3}),
+			},
+			want: [][]interface{}{{1, "a", 7}, {2, "b", 3}},
+		},
+		{
+			name: "added column without a default reads null",
+			steps: []func(Storage) error{
+				insertValues("t", RowValue{"id", 1}, RowValue{"name", "a"}),
+				func(s Storage) error {
+					return s.AddColumn("t", ColumnDefinition{Name: "note", Type: "text"}, nil)
+				},
+			},
+			want: [][]interface{}{{1, "a", nil}},
+		},
+		{
+			name: "dropped column is skipped in older rows",
+			steps: []func(Storage) error{
+				insertValues("t", RowValue{"id", 1}, RowValue{"name", "a"}),
+				func(s Storage) error { return s.DropColumn("t", "name") },
+				insertValues("t", RowValue{"id", 2}),
+			},
+			want: [][]interface{}{{1}, {2}},
+		},
+		{
+			name: "column added again does not read the dropped values",
+			steps: []func(Storage) error{
+				insertValues("t", RowValue{"id", 1}, RowValue{"name", "a"}),
+				func(s Storage) error { return s.DropColumn("t", "name") },
+				func(s Storage) error {
+					return s.AddColumn("t", ColumnDefinition{Name: "name", Type: "text"}, "x")
+				},
+				insertValues("t", RowValue{"id", 2}, RowValue{"name", "b"}),
+			},
+			want: [][]interface{}{{1, "x"}, {2, "b"}},
+		},
+		{
+			name: "renamed column keeps its values",
+			steps: []func(Storage) error{
+				insertValues("t", RowValue{"id", 1}, RowValue{"name", "a"}),
+				func(s Storage) error { return s.RenameColumn("t", "name", "label") },
+				insertValues("t", RowValue{"id", 2}, RowValue{"label", "b"}),
+			},
+			want: [][]interface{}{{1, "a"}, {2, "b"}},
+		},
+		{
+			name: "rows of several versions are decoded with their own columns",
+			steps: []func(Storage) error{
+				insertValues("t", RowValue{"id", 1}, RowValue{"name", "a"}),
+				func(s Storage) error {
+					return s.AddColumn("t", ColumnDefinition{Name: "score", Type: "integer"}, 0)
+				},
+				insertValues("t", RowValue{"id", 2}, RowValue{"name", "b"}, RowValue{"score", 5}),
+				func(s Storage) error { return s.DropColumn("t", "name") },
+				insertValues("t", RowValue{"id", 3}, RowValue{"score", 9}),
+			},
+			want: [][]interface{}{{1, 0}, {2, 5}, {3, 9}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			if err := s.CreateTable("t", columns, nil); err != nil {
+				t.Fatal(err)
+			}
+			for _, step := range test.steps {
+				if err := step(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := tableValues(s, "t"); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got rows %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatVersion(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "data")
+	s, err := newStorage(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateTable("t", []ColumnDefinition{{Name: "id", Type: "integer"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newStorage(filePath); err != nil {
+		t.Fatalf("reopening a data file: %v", err)
+	}
+
+	// Overwrite the catalog with the one of a file written before format
+	// versions were recorded
+	catalog := NewByteStreamBuffer()
+	catalog.WriteInt(int(TableCatalogEntry), SmallIntSize)
+	catalog.WriteString("t")
+	catalog.WriteInt(0, IntSize)
+	if err := s.writePage(TableDefinitionsIndex, catalog.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = newStorage(filePath)
+	want := fmt.Sprintf("data file %s has format version 0, expected %d", filePath, FormatVersion)
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestInsertRowsChecksBeforeWriting(t *testing.T) {
+	columns := []ColumnDefinition{{Name: "id", Type: "integer"}, {Name: "data", Type: "blob"}}
+	tests := []struct {
+		name    string
+		rows    [][]RowValue
+		wantErr string
+	}{
+		{
+			name:    "duplicate key within the batch",
+			rows:    [][]RowValue{{{"id", 1}}, {{"id", 2}}, {{"id", 1}}},
+			wantErr: "duplicate key value violates unique constraint t_pkey",
+		},
+		{
+			name:    "duplicate key of an existing row",
+			rows:    [][]RowValue{{{"id", 2}}, {{"id", 0}}},
+			wantErr: "duplicate key value violates unique constraint t_pkey",
+		},
+		{
+			name:    "row larger than a page",
+			rows:    [][]RowValue{{{"id", 2}, {"data", make([]byte, 20000)}}},
+			wantErr: "row is too big",
+		},
+		{
+			name:    "value of the wrong type",
+			rows:    [][]RowValue{{{"id", 2}}, {{"id", "x"}}},
+			wantErr: "invalid input for type integer",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			constraints := []TableConstraint{{Kind: PrimaryKeyConstraint, Columns: []string{"id"}}}
+			if err := s.CreateTable("t", columns, constraints); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.InsertRows("t", [][]RowValue{{{"id", 0}}}); err != nil {
+				t.Fatal(err)
+			}
+			pages, err := s.tablePages("t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.InsertRows("t", test.rows)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+			if got := tableValues(s, "t"); len(got) != 1 {
+				t.Errorf("got %d rows after a rejected insert, want 1", len(got))
+			}
+			if got, _ := s.tablePages("t"); !reflect.DeepEqual(got, pages) {
+				t.Errorf("got pages %v after a rejected insert, want %v", got, pages)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	s := newTestStorage(t)
+	columns := []ColumnDefinition{{Name: "id", Type: "integer"}, {Name: "data", Type: "blob"}}
+	if err := s.CreateTable("t", columns, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRows("t", [][]RowValue{{{"id", 1}, {"data", []byte{1}}}}); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(s.filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill more than a page, and create another table, so the statement both
+	// changes existing pages and adds new ones
+	if err := s.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i < 5; i++ {
+		if err := s.InsertRows("t", [][]RowValue{{{"id", i}, {"data", make([]byte, 10000)}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CreateTable("u", columns, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := tableValues(s, "t"), [][]interface{}{{1, []byte{1}}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+	if exists, _ := s.relationExists("u"); exists {
+		t.Error("got table u after a rollback")
+	}
+	if got, _ := os.Stat(s.filePath); got.Size() != stat.Size() {
+		t.Errorf("got file size %d after a rollback, want %d", got.Size(), stat.Size())
+	}
+
+	// Changes are kept once committed
+	if err := s.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertRows("t", [][]RowValue{{{"id", 2}, {"data", []byte{2}}}}); err != nil {
+		t.Fatal(err)
+	}
+	s.Commit()
+	if err := s.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if got := tableValues(s, "t"); len(got) != 2 {
+		t.Errorf("got %d rows after a commit, want 2", len(got))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// coerceColumnValue converts a value into the type of a column
+func coerceColumnValue(column ColumnDefinition, value interface{}) (interface{}, error) {
+	var err error
+	if column.Enum != nil && value != nil {
+		value, err = column.Enum.Value(value)
+	} else {
+		value, err = coerceValue(value, column.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for column %s: %s", column.Name, err.Error())
+	}
+	return value, nil
+}
+
+// writeColumnValue writes a non-null value. Null values are not stored, and
+// are marked in null bitmaps instead
+func writeColumnValue(buf *ByteStreamBuffer, column ColumnDefinition, value interface{}) error {
+	value, err := coerceColumnValue(column, value)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("unexpected null value for column %s", column.Name)
+	}
+	// Enums are stored as the position of their label in the type definition
+	if column.Enum != nil {
+		buf.WriteInt(value.(EnumValue).Position, SmallIntSize)
+		return nil
+	}
+	// Arrays are stored as their number of elements, followed by a null bitmap
+	// and each non-null element
+	if elementType, ok := arrayElementType(column.Type); ok {
+		elements := value.([]interface{})
+		buf.WriteInt(len(elements), SmallIntSize)
+		writeNullBitmap(buf, elements)
+		for _, element := range elements {
+			if element == nil {
+				continue
+			}
+			err := writeColumnValue(buf, ColumnDefinition{Name: column.Name, Type: elementType}, element)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch column.Type {
+	case "text", "json":
+		buf.WriteString(value.(string))
+	case "integer":
+		buf.WriteInt(value.(int), IntSize)
+	case "blob":
+		if len(value.([]byte)) > math.MaxUint16 {
+			return fmt.Errorf("value for column %s exceeds %d bytes", column.Name, math.MaxUint16)
+		}
+		buf.WriteBytes(value.([]byte))
+	case "uuid":
+		uuid := value.(UUID)
+		buf.WriteFixedBytes(uuid[:])
+	}
+	return nil
+}
+
+func readColumnValue(buf *ByteStreamBuffer, column ColumnDefinition) interface{} {
+	if column.Enum != nil {
+		return EnumValue{Type: column.Enum, Position: buf.ReadInt(SmallIntSize)}
+	}
+	if elementType, ok := arrayElementType(column.Type); ok {
+		elements := make([]interface{}, buf.ReadInt(SmallIntSize))
+		nulls := readNullBitmap(buf, len(elements))
+		for i := range elements {
+			if !nulls[i] {
+				elements[i] = readColumnValue(buf, ColumnDefinition{Name: column.Name, Type: elementType})
+			}
+		}
+		return elements
+	}
+	switch column.Type {
+	case "text", "json":
+		return buf.ReadString()
+	case "integer":
+		return int(int32(buf.ReadInt(IntSize)))
+	case "blob":
+		return buf.ReadBytes()
+	case "uuid":
+		var uuid UUID
+		copy(uuid[:], buf.ReadFixedBytes(len(uuid)))
+		return uuid
+	}
+	return nil
+}
+
+// writeNullBitmap writes a bitmap with one bit for each value, which is set
+// when the value is null
+func writeNullBitmap(buf *ByteStreamBuffer, values []interface{}) {
+	bitmap := make([]byte, (len(values)+7)/8)
+	for i, value := range values {
+		if value == nil {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+	buf.WriteFixedBytes(bitmap)
+}
+
+func readNullBitmap(buf *ByteStreamBuffer, length int) []bool {
+	bitmap := buf.ReadFixedBytes((length + 7) / 8)
+	nulls := make([]bool, length)
+	for i := range nulls {
+		nulls[i] = bitmap[i/8]&(1<<(i%8)) != 0
+	}
+	return nulls
+}
+
+// arrayElementType returns the type of the elements of an array column type,
+// such as integer for integer[]
+func arrayElementType(columnType string) (string, bool) {
+	if !strings.HasSuffix(columnType, "[]") {
+		return "", false
+	}
+	return strings.TrimSuffix(columnType, "[]"), true
+}
@@ -0,0 +1,120 @@
+package main
+
+// System catalog tables are read-only tables built from the catalog and the
+// page directory whenever they are selected from
+const (
+	CatalogSystemTable = "dbms_catalog"
+	ColumnsSystemTable = "dbms_columns"
+)
+
+var systemTableColumns = map[string][]ColumnDefinition{
+	CatalogSystemTable: {
+		{Name: "type", Type: "text"},
+		{Name: "name", Type: "text"},
+		{Name: "table_name", Type: "text"},
+		{Name: "pages", Type: "integer"},
+		{Name: "temporary", Type: "boolean"},
+	},
+	ColumnsSystemTable: {
+		{Name: "table_name", Type: "text"},
+		{Name: "column_name", Type: "text"},
+		{Name: "position", Type: "integer"},
+		{Name: "data_type", Type: "text"},
+		{Name: "not_null", Type: "boolean"},
+		{Name: "temporary", Type: "boolean"},
+	},
+}
+
+func isSystemTable(name string) bool {
+	_, ok := systemTableColumns[name]
+	return ok
+}
+
+func systemTableDefinition(name string) TableDefinition {
+	tableDefinition := TableDefinition{Name: name, ColumnIndexes: make(map[string]int)}
+	for i, column := range systemTableColumns[name] {
+		tableDefinition.Columns = append(tableDefinition.Columns, column)
+		tableDefinition.ColumnIndexes[column.Name] = i
+	}
+	return tableDefinition
+}
+
+// systemTableRows returns the rows of a system catalog table describing the
+// relations of this storage
+func (s Storage) systemTableRows(name string) ([][]interface{}, error) {
+	if name == ColumnsSystemTable {
+		return s.columnsRows()
+	}
+	return s.catalogRows()
+}
+
+// catalogRows describes each entry of the catalog, along with the number of
+// pages assigned to it in the page directory
+func (s Storage) catalogRows() ([][]interface{}, error) {
+	entries, err := s.readPageDirectory()
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]int)
+	for _, entry := range entries {
+		pages[entry.Table]++
+	}
+
+	var rows [][]interface{}
+	temporary := s.shared != nil
+	materialized := make(map[string]bool)
+	for _, view := range s.views() {
+		rows = append(rows, []interface{}{view.kind(), view.Name, nil, pages[view.Name], temporary})
+		materialized[view.Name] = view.Materialized
+	}
+	for name := range s.catalogEntries(TableCatalogEntry) {
+		if !materialized[name] {
+			rows = append(rows, []interface{}{"table", name, name, pages[name], temporary})
+		}
+	}
+	for name, buf := range s.catalogEntries(IndexCatalogEntry) {
+		rows = append(rows, []interface{}{"index", name, buf.ReadString(), pages[name], temporary})
+	}
+	for _, foreignKey := range s.foreignKeys(func(ForeignKeyDefinition) bool { return true }) {
+		rows = append(rows, []interface{}{"foreign key", foreignKey.Name, foreignKey.Table, 0, temporary})
+	}
+	for name, buf := range s.catalogEntries(SequenceCatalogEntry) {
+		var tableName interface{}
+		if sequence := readSequence(name, &buf); sequence.OwnerTable != "" {
+			tableName = sequence.OwnerTable
+		}
+		rows = append(rows, []interface{}{"sequence", name, tableName, 0, temporary})
+	}
+	for name, buf := range s.catalogEntries(TriggerCatalogEntry) {
+		rows = append(rows, []interface{}{"trigger", name, readTrigger(name, &buf).Table, 0, temporary})
+	}
+	for name := range s.catalogEntries(TypeCatalogEntry) {
+		rows = append(rows, []interface{}{"type", name, nil, 0, temporary})
+	}
+	return rows, nil
+}
+
+// columnsRows describes each column of the tables and views of this storage.
+// Columns of views have no type
+func (s Storage) columnsRows() ([][]interface{}, error) {
+	var rows [][]interface{}
+	temporary := s.shared != nil
+	for _, view := range s.views() {
+		if view.Materialized {
+			continue
+		}
+		for i, column := range view.Columns {
+			rows = append(rows, []interface{}{view.Name, column, i + 1, nil, false, temporary})
+		}
+	}
+	for name := range s.catalogEntries(TableCatalogEntry) {
+		tableDefinition, err := s.GetTableDefinition(name)
+		if err != nil {
+			return nil, err
+		}
+		for i, column := range tableDefinition.Columns {
+			rows = append(rows, []interface{}{name, column.Name, i + 1, column.Type, column.NotNull, temporary})
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestSystemCatalog(t *testing.T) {
+	setup := []string{
+		"create type mood as enum ('a')",
+		"create table t (id integer primary key, m mood, n text not null)",
+		"create table c (id integer references t (id))",
+		"create temp table tt (x text[])",
+		"create view v as select id from t",
+		"create sequence s",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "relations",
+			query: "select type, name, table_name, temporary from dbms_catalog where type <> 'type'",
+			want: [][]string{
+				{"view", "v", "null", "false"},
+				{"table", "t", "t", "false"},
+				{"table", "c", "c", "false"},
+				{"index", "t_pkey", "t", "false"},
+				{"foreign key", "c_id_fkey", "c", "false"},
+				{"sequence", "s", "null", "false"},
+				{"table", "tt", "tt", "true"},
+			},
+		},
+		{
+			name:  "columns of tables",
+			query: "select column_name, position, data_type, not_null from dbms_columns where table_name = 't' order by position",
+			want: [][]string{
+				{"id", "1", "integer", "true"},
+				{"m", "2", "mood", "false"},
+				{"n", "3", "text", "true"},
+			},
+		},
+		{
+			name:  "columns of views",
+			query: "select column_name, data_type, temporary from dbms_columns where table_name = 'v'",
+			want:  [][]string{{"id", "null", "false"}},
+		},
+		{
+			name:  "columns of temporary tables",
+			query: "select column_name, data_type, temporary from dbms_columns where table_name = 'tt'",
+			want:  [][]string{{"x", "text[]", "true"}},
+		},
+		{
+			name:      "columns of materialized views",
+			statement: "create materialized view mv as select id from t",
+			query:     "select column_name, data_type from dbms_columns where table_name = 'mv'",
+			want:      [][]string{{"id", "integer"}},
+		},
+		{
+			name:      "catalog tables cannot be changed",
+			statement: "insert into dbms_catalog (name) values ('x')",
+			wantErr:   "dbms_catalog is a read-only system catalog table",
+		},
+		{
+			name:      "catalog tables cannot be dropped",
+			statement: "drop table dbms_columns",
+			wantErr:   "dbms_columns is a read-only system catalog table",
+		},
+		{
+			name:      "catalog table names are reserved",
+			statement: "create table dbms_catalog (id integer)",
+			wantErr:   "relation dbms_catalog already exists",
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Trigger struct {
+	Name   string
+	Table  string
+	Timing TriggerTiming
+	Event  TriggerEvent
+	// Body holds the text of the statements run when the trigger fires
+	Body []string
+}
+
+func (s Storage) CreateTrigger(trigger Trigger) error {
+	_, found, err := s.GetTrigger(trigger.Name)
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("trigger %s already exists", trigger.Name)
+	}
+	return s.writeTrigger(trigger)
+}
+
+func (s Storage) DropTrigger(triggerName string) error {
+	found, err := s.removeCatalogEntry(TriggerCatalogEntry, triggerName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("trigger %s does not exist", triggerName)
+	}
+	return nil
+}
+
+// GetTrigger returns the definition of a trigger, and false when there is no
+// trigger with the given name
+func (s Storage) GetTrigger(triggerName string) (Trigger, bool, error) {
+	buf, _, found, err := s.findCatalogEntry(TriggerCatalogEntry, triggerName)
+	if err != nil || !found {
+		return Trigger{}, false, err
+	}
+	return readTrigger(triggerName, &buf), true, nil
+}
+
+// tableTriggers returns the triggers of a table, sorted by name as that is
+// the order in which they fire
+func (s Storage) tableTriggers(tableName string) []Trigger {
+	var triggers []Trigger
+	for name, buf := range s.catalogEntries(TriggerCatalogEntry) {
+		if trigger := readTrigger(name, &buf); trigger.Table == tableName {
+			triggers = append(triggers, trigger)
+		}
+	}
+	sort.Slice(triggers, func(i, j int) bool { return triggers[i].Name < triggers[j].Name })
+	return triggers
+}
+
+// writeTrigger replaces the definition of a trigger in the table definitions
+// page
+func (s Storage) writeTrigger(trigger Trigger) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteString(trigger.Table)
+	buf.WriteInt(int(trigger.Timing), SmallIntSize)
+	buf.WriteInt(int(trigger.Event), SmallIntSize)
+	buf.WriteInt(len(trigger.Body), SmallIntSize)
+	for _, statement := range trigger.Body {
+		buf.WriteString(statement)
+	}
+
+	if _, err := s.removeCatalogEntry(TriggerCatalogEntry, trigger.Name); err != nil {
+		return err
+	}
+	return s.addCatalogEntry(TriggerCatalogEntry, trigger.Name, buf)
+}
+
+func readTrigger(name string, buf *ByteStreamBuffer) Trigger {
+	trigger := Trigger{
+		Name:   name,
+		Table:  buf.ReadString(),
+		Timing: TriggerTiming(buf.ReadInt(SmallIntSize)),
+		Event:  TriggerEvent(buf.ReadInt(SmallIntSize)),
+	}
+	statements := buf.ReadInt(SmallIntSize)
+	for i := 0; i < statements; i++ {
+		trigger.Body = append(trigger.Body, buf.ReadString())
+	}
+	return trigger
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+type UUID [16]byte
+
+func NewRandomUUID() (UUID, error) {
+	var uuid UUID
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return uuid, err
+	}
+	// Set version 4 and RFC 4122 variant bits
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return uuid, nil
+}
+
+func ParseUUID(value string) (UUID, error) {
+	var uuid UUID
+	if len(value) != 36 || value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+		return uuid, fmt.Errorf("invalid uuid %s", value)
+	}
+	digits := value[0:8] + value[9:13] + value[14:18] + value[19:23] + value[24:36]
+	if _, err := hex.Decode(uuid[:], []byte(digits)); err != nil {
+		return uuid, fmt.Errorf("invalid uuid %s", value)
+	}
+	return uuid, nil
+}
+
+func (uuid UUID) String() string {
+	digits := hex.EncodeToString(uuid[:])
+	return digits[0:8] + "-" + digits[8:12] + "-" + digits[12:16] + "-" + digits[16:20] + "-" + digits[20:32]
+}
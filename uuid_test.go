@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUUID(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "6f1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5f", want: "6f1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5f"},
+		{input: "6F1C2B0E-3D4A-4C5B-9E8F-0A1B2C3D4E5F", want: "6f1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5f"},
+		{input: "6f1c2b0e3d4a4c5b9e8f0a1b2c3d4e5f", wantErr: true},
+		{input: "6f1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5", wantErr: true},
+		{input: "zf1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5f", wantErr: true},
+	}
+	for _, test := range tests {
+		uuid, err := ParseUUID(test.input)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseUUID(%q) got %s, want an error", test.input, uuid)
+			}
+			continue
+		}
+		if err != nil || uuid.String() != test.want {
+			t.Errorf("ParseUUID(%q) got %s, %v, want %s", test.input, uuid, err, test.want)
+		}
+	}
+}
+
+func TestNewRandomUUID(t *testing.T) {
+	a, err := NewRandomUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewRandomUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("got the same uuid %s twice", a)
+	}
+	// Random uuids are version 4, with the RFC 4122 variant
+	if s := a.String(); s[14] != '4' || !strings.ContainsRune("89ab", rune(s[19])) {
+		t.Errorf("got uuid %s, want a version 4 uuid", s)
+	}
+}
+
+func TestUuidColumns(t *testing.T) {
+	setup := []string{
+		"create table t (id uuid, n integer)",
+		"insert into t (id, n) values ('6F1C2B0E-3D4A-4C5B-9E8F-0A1B2C3D4E5F', 1)",
+		"insert into t (id, n) values (gen_random_uuid(), 2)",
+	}
+	runSQLTests(t, setup, []sqlTest{
+		{
+			name:  "text is converted into uuids",
+			query: "select id, n from t where id = '6f1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5f'",
+			want:  [][]string{{"6f1c2b0e-3d4a-4c5b-9e8f-0a1b2c3d4e5f", "1"}},
+		},
+		{
+			name:  "generated uuids are stored",
+			query: "select n from t where id = gen_random_uuid()",
+			want:  nil,
+		},
+		{
+			name:      "invalid uuids are rejected",
+			statement: "insert into t (id, n) values ('nope', 3)",
+			wantErr:   "invalid uuid nope",
+			query:     "select count() from t",
+			want:      [][]string{{"2"}},
+		},
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import "fmt"
+
+type View struct {
+	Name    string
+	Columns []string
+	// Query is the text of the select statement defining the view, which is
+	// parsed again whenever the view is used
+	Query string
+	// Materialized views store the result of their query in table pages, under
+	// a table definition of the same name
+	Materialized bool
+}
+
+// kind names the kind of relation of a view in messages
+func (view View) kind() string {
+	if view.Materialized {
+		return "materialized view"
+	}
+	return "view"
+}
+
+func (s Storage) CreateView(view View) error {
+	exists, err := s.relationExists(view.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("relation %s already exists", view.Name)
+	}
+	return s.writeView(view)
+}
+
+// CreateMaterializedView creates a table for the result of the query of a
+// view, with the given rows
+func (s Storage) CreateMaterializedView(view View, columns []ColumnDefinition, rows [][]RowValue) error {
+	if err := s.CreateTable(view.Name, columns, nil); err != nil {
+		return err
+	}
+	if err := s.RefreshMaterializedView(view.Name, rows); err != nil {
+		s.DropTable(view.Name)
+		return err
+	}
+	view.Materialized = true
+	return s.writeView(view)
+}
+
+// RefreshMaterializedView replaces the rows of a materialized view. Every row
+// is encoded before anything is written, so the previous rows are kept when
+// any of them is invalid
+func (s Storage) RefreshMaterializedView(viewName string, rows [][]RowValue) error {
+	tableDefinition, err := s.GetTableDefinition(viewName)
+	if err != nil {
+		return err
+	}
+
+	var entries [][]byte
+	for _, row := range rows {
+		buf, err := s.encodeRow(tableDefinition, row)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, buf.Bytes())
+	}
+	return s.writeRows(viewName, entries)
+}
+
+func (s Storage) DropView(viewName string) error {
+	view, found, err := s.GetView(viewName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("view %s does not exist", viewName)
+	}
+	if view.Materialized {
+		if err := s.DropTable(viewName); err != nil {
+			return err
+		}
+	}
+	_, err = s.removeCatalogEntry(ViewCatalogEntry, viewName)
+	return err
+}
+
+// GetView returns the definition of a view, and false when there is no view
+// with the given name
+func (s Storage) GetView(viewName string) (View, bool, error) {
+	buf, _, found, err := s.findCatalogEntry(ViewCatalogEntry, viewName)
+	if err != nil || !found {
+		return View{}, false, err
+	}
+	return readView(viewName, &buf), true, nil
+}
+
+// views returns the definitions of all views
+func (s Storage) views() []View {
+	var views []View
+	for name, buf := range s.catalogEntries(ViewCatalogEntry) {
+		views = append(views, readView(name, &buf))
+	}
+	return views
+}
+
+func (s Storage) writeView(view View) error {
+	buf := NewByteStreamBuffer()
+	buf.WriteString(view.Query)
+	buf.WriteInt(boolToInt(view.Materialized), SmallIntSize)
+	buf.WriteInt(len(view.Columns), SmallIntSize)
+	for _, column := range view.Columns {
+		buf.WriteString(column)
+	}
+	return s.addCatalogEntry(ViewCatalogEntry, view.Name, buf)
+}
+
+func readView(name string, buf *ByteStreamBuffer) View {
+	view := View{Name: name, Query: buf.ReadString(), Materialized: buf.ReadInt(SmallIntSize) == 1}
+	columns := buf.ReadInt(SmallIntSize)
+	for i := 0; i < columns; i++ {
+		view.Columns = append(view.Columns, buf.ReadString())
+	}
+	return view
+}